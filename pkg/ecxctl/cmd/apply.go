@@ -0,0 +1,174 @@
+// Copyright © 2018 Juan Manuel Irigaray <jirigaray@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/jxoir/equinix-tools/pkg/ecxlib/api/buyer"
+	"github.com/spf13/cobra"
+	yaml "gopkg.in/yaml.v2"
+)
+
+var applyFile string
+var applyPrune bool
+var applyDryRun bool
+var applyYes bool
+
+var applyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "create, update (and with --prune delete) connections to match a manifest",
+	Long: `Compares the connections declared in a YAML manifest with the buyer connections and applies the changes
+after confirmation. Connections are identified by name, port and vlan.
+
+connections:
+  - name: AWS_LD_PRIMARY
+    portUUID: 66284add-86ff-6ff0-b4e0-30ac094f8af1
+    vlanSTag: 1010
+    sellerProfileUUID: 69ee618d-be52-468d-bc99-00566f2dd2b9
+    sellerMetroCode: LD
+    sellerRegion: eu-west-2
+    authorizationKey: "123456789012"
+    speed: 100
+    speedUnit: MB
+    notifications: [noc@example.com]
+    secondary:
+      name: AWS_LD_SECONDARY
+      portUUID: 66284add-86ff-6ff0-b4e0-30ac094f8af2
+      vlanSTag: 1010`,
+	Run: applyCommand,
+}
+
+func init() {
+	rootCmd.AddCommand(applyCmd)
+
+	applyCmd.Flags().StringVarP(&applyFile, "file", "f", "", "manifest with the desired connections, - reads stdin (requires --yes or --dry-run)")
+	applyCmd.Flags().BoolVar(&applyPrune, "prune", false, "delete connections not declared in the manifest")
+	applyCmd.Flags().BoolVar(&applyDryRun, "dry-run", false, "only print the plan")
+	applyCmd.Flags().BoolVarP(&applyYes, "yes", "y", false, "apply without asking for confirmation")
+	applyCmd.MarkFlagRequired("file")
+}
+
+func applyCommand(cmd *cobra.Command, args []string) {
+	// the confirmation is read from stdin too
+	if applyFile == "-" && !applyYes && !applyDryRun {
+		log.Fatal("Reading the manifest from stdin requires --yes or --dry-run")
+	}
+
+	manifest, err := readManifest(applyFile)
+	if err != nil {
+		log.Fatalf("Error reading manifest %s: %s\n", applyFile, err)
+	}
+
+	current, err := ConnectionsAPIClient.GetAllBuyerConnections(nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	plan, err := buyer.PlanConnections(manifest.Connections, current.Items, applyPrune)
+	if err != nil {
+		log.Fatalf("Error planning changes: %s\n", err)
+	}
+
+	if plan.Empty() {
+		fmt.Println("No changes, connections match the manifest")
+		return
+	}
+	printPlan(plan)
+
+	if applyDryRun {
+		return
+	}
+	if !applyYes && !confirm("Apply these changes?") {
+		fmt.Println("Apply cancelled")
+		return
+	}
+
+	failed := 0
+	for _, result := range ConnectionsAPIClient.ApplyPlan(plan, SellerServicesAPIClient) {
+		if result.Err != nil {
+			failed++
+			fmt.Printf("Error %s connection %s: %s\n", actionVerb(result.Change.Action), result.Change.Name, result.Err)
+			continue
+		}
+		fmt.Printf("Connection %s %sd %s\n", result.Change.Name, result.Change.Action, strings.Join(result.UUIDs, ", "))
+	}
+	if failed > 0 {
+		log.Fatalf("%d of %d changes failed\n", failed, len(plan.Changes))
+	}
+}
+
+// readManifest parses the manifest at path, unknown fields are errors so typos don't go unnoticed
+func readManifest(path string) (*buyer.ConnectionManifest, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		data, err = ioutil.ReadFile(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &buyer.ConnectionManifest{}
+	if err := yaml.UnmarshalStrict(data, manifest); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// printPlan prints the changes of plan, terraform style
+func printPlan(plan *buyer.ConnectionPlan) {
+	for _, change := range plan.Changes {
+		switch change.Action {
+		case buyer.PlanCreate:
+			spec := change.Spec
+			fmt.Printf("+ create %s (port %s vlan %d, %d %s)\n", spec.Name, spec.PortUUID, spec.VlanSTag, spec.Speed, spec.SpeedUnit)
+			if spec.Secondary != nil {
+				fmt.Printf("    secondary %s (port %s vlan %d)\n", spec.Secondary.Name, spec.Secondary.PortUUID, spec.Secondary.VlanSTag)
+			}
+		case buyer.PlanUpdate:
+			fmt.Printf("~ update %s (%s)\n", change.Name, change.Current.UUID)
+			for _, diff := range change.Diff {
+				fmt.Printf("    %s: %s -> %s\n", diff.Field, diff.From, diff.To)
+			}
+		case buyer.PlanDelete:
+			fmt.Printf("- delete %s (%s)\n", change.Name, change.Current.UUID)
+		}
+	}
+	fmt.Printf("\nPlan: %d to create, %d to update, %d to delete.\n",
+		plan.Count(buyer.PlanCreate), plan.Count(buyer.PlanUpdate), plan.Count(buyer.PlanDelete))
+}
+
+// actionVerb returns the progressive form of action for error messages
+func actionVerb(action buyer.PlanAction) string {
+	return strings.TrimSuffix(string(action), "e") + "ing"
+}
+
+// confirm asks a yes/no question on the terminal, anything but yes is a no
+func confirm(question string) bool {
+	fmt.Printf("%s Only 'yes' will be accepted: ", question)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && answer == "" {
+		return false
+	}
+	return strings.TrimSpace(strings.ToLower(answer)) == "yes"
+}
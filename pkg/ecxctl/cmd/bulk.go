@@ -0,0 +1,272 @@
+// Copyright © 2018 Juan Manuel Irigaray <jirigaray@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/jxoir/equinix-tools/pkg/ecxlib/api/buyer"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// flags of connections create --from-file
+var createFromFile string
+var createResultsFile string
+var createConcurrency int
+
+// connectionsCreateFromFileCommand validates every row of --from-file, creates the connections and writes the results
+func connectionsCreateFromFileCommand() {
+	rows, err := readConnectionRows(createFromFile)
+	if err != nil {
+		log.Fatalf("Error reading %s: %s\n", createFromFile, err)
+	}
+	if len(rows) == 0 {
+		log.Fatalf("No connections found in %s\n", createFromFile)
+	}
+
+	existing, err := ConnectionsAPIClient.GetAllBuyerConnections(nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	resultsFile := createResultsFile
+	if resultsFile == "" {
+		resultsFile = strings.TrimSuffix(createFromFile, filepath.Ext(createFromFile)) + ".results.csv"
+	}
+
+	invalid := 0
+	errs := buyer.ValidateConnectionRows(rows, existing.Items)
+	results := make([]*buyer.BulkCreateResult, len(rows))
+	for i, err := range errs {
+		results[i] = &buyer.BulkCreateResult{Row: i, Params: rows[i], Err: err}
+		if err != nil {
+			invalid++
+			fmt.Printf("Row %d: %s\n", i+1, err)
+		}
+	}
+	if invalid > 0 {
+		if err := writeBulkResults(resultsFile, results); err != nil {
+			log.Println("Error writing results:", err)
+		}
+		log.Fatalf("%d of %d rows are invalid, no connection was created\n", invalid, len(rows))
+	}
+
+	var seller *buyer.ECXSellerServicesAPI
+	if createL2CSP {
+		seller = SellerServicesAPIClient
+	}
+	results = ConnectionsAPIClient.CreateL2Connections(rows, createConcurrency, seller)
+
+	failed := 0
+	var uuids []string
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+			fmt.Printf("Row %d: error creating connection %s: %s\n", result.Row+1, result.Params.PrimaryName, result.Err)
+			continue
+		}
+		fmt.Printf("Row %d: connection %s succesfully created\n", result.Row+1, result.PrimaryUUID)
+		uuids = append(uuids, result.PrimaryUUID)
+		if result.SecondaryUUID != "" {
+			uuids = append(uuids, result.SecondaryUUID)
+		}
+	}
+
+	if err := writeBulkResults(resultsFile, results); err != nil {
+		log.Println("Error writing results:", err)
+	} else {
+		fmt.Printf("Results written to %s\n", resultsFile)
+	}
+
+	if connectionWait && len(uuids) > 0 {
+		waitForConnections(uuids, buyer.ConnectionStatusProvisioned)
+	}
+	if failed > 0 {
+		log.Fatalf("%d of %d connections failed\n", failed, len(rows))
+	}
+}
+
+// readConnectionRows reads the connections of a csv file, whose header has the CreateL2ConnectionParams json field
+// names, or of a yaml (or json) list
+func readConnectionRows(path string) ([]*buyer.CreateL2ConnectionParams, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml", ".json":
+		var rows []*buyer.CreateL2ConnectionParams
+		if err := yaml.UnmarshalStrict(data, &rows); err != nil {
+			return nil, err
+		}
+		return rows, nil
+	case ".csv":
+		return parseConnectionsCSV(string(data))
+	}
+	return nil, fmt.Errorf("unknown file type %q, expected .csv, .yaml or .json", filepath.Ext(path))
+}
+
+// parseConnectionsCSV maps every record to CreateL2ConnectionParams by the json names of the header columns,
+// notifications are separated by ';'
+func parseConnectionsCSV(data string) ([]*buyer.CreateL2ConnectionParams, error) {
+	records, err := csv.NewReader(strings.NewReader(data)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	paramsType := reflect.TypeOf(buyer.CreateL2ConnectionParams{})
+	fields := map[string]int{}
+	for i := 0; i < paramsType.NumField(); i++ {
+		name := strings.Split(paramsType.Field(i).Tag.Get("json"), ",")[0]
+		fields[strings.ToLower(name)] = i
+	}
+
+	header := records[0]
+	columns := make([]int, len(header))
+	for i, name := range header {
+		field, ok := fields[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			return nil, fmt.Errorf("unknown column %q", name)
+		}
+		columns[i] = field
+	}
+
+	rows := make([]*buyer.CreateL2ConnectionParams, 0, len(records)-1)
+	for line, record := range records[1:] {
+		row := &buyer.CreateL2ConnectionParams{}
+		v := reflect.ValueOf(row).Elem()
+		for i, value := range record {
+			value = strings.TrimSpace(value)
+			if value == "" {
+				continue
+			}
+			field := v.Field(columns[i])
+			switch field.Kind() {
+			case reflect.String:
+				field.SetString(value)
+			case reflect.Int64:
+				n, err := strconv.ParseInt(value, 10, 64)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: invalid %s %q", line+2, header[i], value)
+				}
+				field.SetInt(n)
+			case reflect.Slice:
+				for _, item := range strings.Split(value, ";") {
+					if item = strings.TrimSpace(item); item != "" {
+						field.Set(reflect.Append(field, reflect.ValueOf(item)))
+					}
+				}
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// writeBulkResults writes a csv with the connection uuids or the error of every row
+func writeBulkResults(path string, results []*buyer.BulkCreateResult) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	w := csv.NewWriter(f)
+	w.Write([]string{"row", "primaryName", "secondaryName", "primaryConnectionId", "secondaryConnectionId", "error"})
+	for _, result := range results {
+		params := result.Params
+		if params == nil {
+			params = &buyer.CreateL2ConnectionParams{}
+		}
+		var errMsg string
+		if result.Err != nil {
+			errMsg = result.Err.Error()
+		}
+		w.Write([]string{
+			strconv.Itoa(result.Row + 1),
+			params.PrimaryName,
+			params.SecondaryName,
+			result.PrimaryUUID,
+			result.SecondaryUUID,
+			errMsg,
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// readUUIDs reads connection uuids from a file with one uuid per line ('#' starts a comment) or from a csv with uuid,
+// primaryConnectionId or secondaryConnectionId columns, such as the results of create --from-file
+func readUUIDs(path string) ([]string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var uuids []string
+	if strings.ToLower(filepath.Ext(path)) == ".csv" {
+		records, err := csv.NewReader(strings.NewReader(string(data))).ReadAll()
+		if err != nil {
+			return nil, err
+		}
+		if len(records) == 0 {
+			return nil, nil
+		}
+
+		var columns []int
+		for i, name := range records[0] {
+			switch strings.ToLower(strings.TrimSpace(name)) {
+			case "uuid", "primaryconnectionid", "secondaryconnectionid":
+				columns = append(columns, i)
+			}
+		}
+		if len(columns) == 0 {
+			return nil, fmt.Errorf("no uuid, primaryConnectionId or secondaryConnectionId column")
+		}
+		for _, record := range records[1:] {
+			for _, i := range columns {
+				if i < len(record) && strings.TrimSpace(record[i]) != "" {
+					uuids = append(uuids, strings.TrimSpace(record[i]))
+				}
+			}
+		}
+		return uuids, nil
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		if line = strings.TrimSpace(line); line != "" {
+			uuids = append(uuids, line)
+		}
+	}
+	return uuids, nil
+}
@@ -0,0 +1,156 @@
+// Copyright © 2018 Juan Manuel Irigaray <jirigaray@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/csv"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jxoir/equinix-tools/pkg/ecxlib/api/buyer"
+)
+
+func TestParseConnectionsCSV(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		rows []*buyer.CreateL2ConnectionParams
+		err  string
+	}{
+		{
+			name: "fields",
+			data: "primaryName, PrimaryPortUUID ,primaryVlanSTag,speed,speedUnit,notifications\n" +
+				"AWS-1,port-1,100,50,MB,noc@example.com; ops@example.com;\n" +
+				"AWS-2,port-2,,1,GB,\n",
+			rows: []*buyer.CreateL2ConnectionParams{
+				{PrimaryName: "AWS-1", PrimaryPortUUID: "port-1", PrimaryVlanSTag: 100, Speed: 50, SpeedUnit: "MB",
+					Notifications: []string{"noc@example.com", "ops@example.com"}},
+				{PrimaryName: "AWS-2", PrimaryPortUUID: "port-2", Speed: 1, SpeedUnit: "GB"},
+			},
+		},
+		{
+			name: "header only",
+			data: "primaryName,primaryPortUUID\n",
+			rows: []*buyer.CreateL2ConnectionParams{},
+		},
+		{
+			name: "empty",
+			data: "",
+		},
+		{
+			name: "unknown column",
+			data: "primaryName,vlan\nAWS-1,100\n",
+			err:  `unknown column "vlan"`,
+		},
+		{
+			name: "invalid int",
+			data: "primaryName,primaryVlanSTag\nAWS-1,100\nAWS-2,abc\n",
+			err:  `line 3: invalid primaryVlanSTag "abc"`,
+		},
+	}
+
+	for _, test := range tests {
+		rows, err := parseConnectionsCSV(test.data)
+		if test.err != "" {
+			if err == nil || err.Error() != test.err {
+				t.Errorf("%s: expected error %q, received %v", test.name, test.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error %s", test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(rows, test.rows) {
+			t.Errorf("%s: expected %+v, received %+v", test.name, test.rows, rows)
+		}
+	}
+}
+
+func TestReadConnectionRows(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"rows.csv":  "primaryName,primaryVlanSTag\nAWS-1,100\n",
+		"rows.yaml": "- primaryName: AWS-1\n  primaryVlanSTag: 100\n",
+		"rows.json": `[{"primaryName": "AWS-1", "primaryVlanSTag": 100}]`,
+		"typo.yaml": "- primaryName: AWS-1\n  vlan: 100\n",
+		"rows.txt":  "AWS-1\n",
+	}
+	for name, data := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected := []*buyer.CreateL2ConnectionParams{{PrimaryName: "AWS-1", PrimaryVlanSTag: 100}}
+	for _, name := range []string{"rows.csv", "rows.yaml", "rows.json"} {
+		rows, err := readConnectionRows(filepath.Join(dir, name))
+		if err != nil || !reflect.DeepEqual(rows, expected) {
+			t.Errorf("%s: expected %+v, received %+v %v", name, expected, rows, err)
+		}
+	}
+	for _, name := range []string{"typo.yaml", "rows.txt", "missing.csv"} {
+		if _, err := readConnectionRows(filepath.Join(dir, name)); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestWriteBulkResults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rows.results.csv")
+	results := []*buyer.BulkCreateResult{
+		{Row: 0, Params: &buyer.CreateL2ConnectionParams{PrimaryName: "AWS-1", SecondaryName: "AWS-1-SEC"},
+			PrimaryUUID: "uuid-1", SecondaryUUID: "uuid-2"},
+		{Row: 1, Params: &buyer.CreateL2ConnectionParams{PrimaryName: "AWS-2"}, Err: errors.New("vlan already used")},
+		{Row: 2},
+	}
+	if err := writeBulkResults(path, results); err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := [][]string{
+		{"row", "primaryName", "secondaryName", "primaryConnectionId", "secondaryConnectionId", "error"},
+		{"1", "AWS-1", "AWS-1-SEC", "uuid-1", "uuid-2", ""},
+		{"2", "AWS-2", "", "", "", "vlan already used"},
+		{"3", "", "", "", "", ""},
+	}
+	if !reflect.DeepEqual(records, expected) {
+		t.Errorf("Expected %v, received %v", expected, records)
+	}
+
+	// the results are accepted by delete --from-file
+	uuids, err := readUUIDs(path)
+	if err != nil || strings.Join(uuids, ",") != "uuid-1,uuid-2" {
+		t.Errorf("Expected uuid-1 and uuid-2, received %v %v", uuids, err)
+	}
+
+	if err := writeBulkResults(filepath.Join(path, "missing", "results.csv"), results); err == nil {
+		t.Errorf("Expected error writing to a missing directory")
+	}
+}
@@ -15,18 +15,36 @@
 package cmd
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
 	"log"
-
-	apiconnections "github.com/jxoir/go-ecxfabric/buyer/client/connections"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jxoir/equinix-tools/pkg/ecxlib/api/buyer"
+	client "github.com/jxoir/equinix-tools/pkg/ecxlib/api/client"
+	"github.com/jxoir/go-ecxfabric/buyer/models"
 	"github.com/spf13/cobra"
 )
 
 var filterValues string
-var deleteUUID string
+
+// vars for delete connection command
+var deleteUUIDs []string
+var deleteFilter string
+var deleteFromFile string
+var deleteDryRun bool
+var deleteYes bool
+var deleteConcurrency int
 var connectionMetro string
 
+// wait for connections to be provisioned (create) or deprovisioned (delete)
+var connectionWait bool
+var connectionWaitTimeout time.Duration
+
 // flag to call wrapper around l2 connection
 var createL2CSP bool
 
@@ -39,6 +57,7 @@ var createL2ConPrimaryName string
 var createL2ConPrimaryPortUUID string
 var createL2ConPrimaryVlanCTag string // should be casted to int64 - Inner tag
 var createL2ConPrimaryVlanSTag int64  // should be casted to int64 - Outer tag
+var createL2ConPrimaryVlanSTagValue string
 
 var createL2ConPrimaryZSidePortUUID string // should be casted to int64
 var createL2ConPrimaryZSideVlanCTag string // should be casted to int64
@@ -51,6 +70,10 @@ var createL2ConSecondaryName string
 var createL2ConSecondaryPortUUID string
 var createL2ConSecondaryVlanCTag string
 var createL2ConSecondaryVlanSTag int64
+var createL2ConSecondaryVlanSTagValue string
+
+// range of vlans allocated by --port-stag auto
+var createVlanRange string
 
 var createL2ConSecondaryZSidePortUUID string // should be casted to int64
 var createL2ConSecondaryZSideVlanCTag int64  // should be casted to int64
@@ -62,6 +85,20 @@ var createL2ConSellerRegion string
 var createL2ConSpeed int64 // should be casted to int64
 var createL2ConSpeedUnit string
 
+// vars for update connection command
+var updateConName string
+var updateConSpeed int64
+var updateConSpeedUnit string
+var updateConNotifications []string
+var updateConAddNotifications []string
+var updateConRemoveNotifications []string
+
+// vars for validate auth key command
+var validateAuthKeySellerUUID string
+var validateAuthKey string
+var validateAuthKeyMetro string
+var validateAuthKeyRegion string
+
 var connectionsCmd = &cobra.Command{
 	Use:   "connections",
 	Short: "Operations related to ECX connections (buyer)",
@@ -81,10 +118,9 @@ var connectionsGetCmd = &cobra.Command{
 }
 
 var connectionsDeleteCmd = &cobra.Command{
-	Use:   "delete",
-	Short: "delete specific connection by uuid",
-	//Args:  cobra.MinimumNArgs(1),
-	Run: connectionsDeleteByUUIDCommand,
+	Use:   "delete [uuid...]",
+	Short: "delete connections by uuid, filter or file",
+	Run:   connectionsDeleteByUUIDCommand,
 }
 
 var connectionsCreateL2Cmd = &cobra.Command{
@@ -93,18 +129,40 @@ var connectionsCreateL2Cmd = &cobra.Command{
 	Run:   connectionsCreateCommand,
 }
 
+var connectionsUpdateCmd = &cobra.Command{
+	Use:   "update <uuid>",
+	Short: "modify connection name, speed or notification emails",
+	Args:  cobra.ExactArgs(1),
+	Run:   connectionsUpdateCommand,
+}
+
+var connectionsValidateAuthKeyCmd = &cobra.Command{
+	Use:   "validate-auth-key",
+	Short: "validate a seller authorization key (AWS account ID, Azure service key, Google pairing key...)",
+	Run:   connectionsValidateAuthKeyCommand,
+}
+
 func init() {
 	rootCmd.AddCommand(connectionsCmd)
 	connectionsCmd.AddCommand(connectionsListCmd)
 	connectionsCmd.AddCommand(connectionsGetCmd)
 	connectionsCmd.AddCommand(connectionsDeleteCmd)
 	connectionsCmd.AddCommand(connectionsCreateL2Cmd)
+	connectionsCmd.AddCommand(connectionsUpdateCmd)
+	connectionsCmd.AddCommand(connectionsValidateAuthKeyCmd)
 
-	connectionsListCmd.PersistentFlags().StringVarP(&filterValues, "filter", "f", "", "Comma separated key-value pair of filter (eg.: filter=Key=Name,Value=ECX)")
+	connectionsListCmd.PersistentFlags().StringVarP(&filterValues, "filter", "f", "", "Filter expression (eg.: 'speed>=500 and status=PROVISIONED and createdDate>2024-01-01')")
 	connectionsListCmd.PersistentFlags().StringVarP(&connectionMetro, "metro", "", "", "Filter metro code (ex.: LD)")
+	addListFlags(connectionsListCmd)
 
-	connectionsDeleteCmd.Flags().StringVarP(&deleteUUID, "uuid", "u", "", "*connection* to delete")
-	connectionsDeleteCmd.MarkFlagRequired("uuid")
+	connectionsDeleteCmd.Flags().StringSliceVarP(&deleteUUIDs, "uuid", "u", nil, "*connection* to delete, can be repeated")
+	connectionsDeleteCmd.Flags().StringVarP(&deleteFilter, "filter", "f", "", "delete the connections matching a filter expression (eg.: 'status=PROVISIONED and name~^test-')")
+	connectionsDeleteCmd.Flags().StringVarP(&deleteFromFile, "from-file", "", "", "delete the connections of a file, one uuid per line or a csv with uuid or primaryConnectionId/secondaryConnectionId columns")
+	connectionsDeleteCmd.Flags().BoolVar(&deleteDryRun, "dry-run", false, "only print the connections that would be deleted")
+	connectionsDeleteCmd.Flags().BoolVarP(&deleteYes, "yes", "y", false, "delete without asking for confirmation")
+	connectionsDeleteCmd.Flags().IntVarP(&deleteConcurrency, "concurrency", "", buyer.DefaultBulkConcurrency, "connections deleted at the same time")
+	connectionsDeleteCmd.Flags().BoolVar(&connectionWait, "wait", false, "wait until the connection is deprovisioned")
+	connectionsDeleteCmd.Flags().DurationVar(&connectionWaitTimeout, "timeout", 15*time.Minute, "maximum time to wait with --wait")
 
 	connectionsCreateL2Cmd.Flags().BoolVarP(&createL2CSP, "cloud", "c", false, "connect to a public cloud provider ex.: Azure, AWS, Google")
 	connectionsCreateL2Cmd.Flags().StringVarP(&createL2ConAuthorizationKey, "auth-key", "", "", "service authorization key (in AWS case use AWS Account ID)")
@@ -115,7 +173,7 @@ func init() {
 	connectionsCreateL2Cmd.Flags().StringVarP(&createL2ConSecondaryName, "sec-name", "", "", "name for the secondary connection")
 
 	connectionsCreateL2Cmd.Flags().StringVarP(&createL2ConPrimaryPortUUID, "port-uuid", "", "", "user port uuid")
-	connectionsCreateL2Cmd.Flags().Int64VarP(&createL2ConPrimaryVlanSTag, "port-stag", "", 0, "S-Tag/Outer-tag of the primary port (vlan id for Dot1Q)")
+	connectionsCreateL2Cmd.Flags().StringVarP(&createL2ConPrimaryVlanSTagValue, "port-stag", "", "", "S-Tag/Outer-tag of the primary port (vlan id for Dot1Q), auto picks the first free vlan of --vlan-range")
 	connectionsCreateL2Cmd.Flags().StringVarP(&createL2ConPrimaryVlanCTag, "port-ctag", "", "", "C-Tag/Inner-tag of the primary port (customer vlan id for QinQ)")
 
 	connectionsCreateL2Cmd.Flags().StringVarP(&createL2ConPrimaryZSidePortUUID, "port-zside-uuid", "", "", "Z-side (remote) user port uuid")
@@ -123,7 +181,8 @@ func init() {
 	connectionsCreateL2Cmd.Flags().StringVarP(&createL2ConPrimaryZSideVlanCTag, "port-zside-ctag", "", "", "Z-side (remote) C-Tag/Inner-tag of the primary port (customer vlan id for QinQ)")
 
 	connectionsCreateL2Cmd.Flags().StringVarP(&createL2ConSecondaryPortUUID, "sec-port-uuid", "", "", "Z-side (remote) secondary user port uuid")
-	connectionsCreateL2Cmd.Flags().Int64VarP(&createL2ConSecondaryVlanSTag, "sec-port-stag", "", 0, "Z-side (remote) S-Tag/Outer-tag of the secondary port (vlan id for Dot1Q)")
+	connectionsCreateL2Cmd.Flags().StringVarP(&createL2ConSecondaryVlanSTagValue, "sec-port-stag", "", "", "Z-side (remote) S-Tag/Outer-tag of the secondary port (vlan id for Dot1Q), auto picks the first free vlan of --vlan-range")
+	connectionsCreateL2Cmd.Flags().StringVarP(&createVlanRange, "vlan-range", "", defaultVlanRange, "range of vlans for --port-stag auto and --sec-port-stag auto")
 	connectionsCreateL2Cmd.Flags().StringVarP(&createL2ConSecondaryVlanCTag, "sec-port-ctag", "", "", "Z-side (remote) C-Tag/Inner-tag of the primary port (customer vlan id for QinQ)")
 
 	connectionsCreateL2Cmd.Flags().StringVarP(&createL2ConSecondaryZSidePortUUID, "sec-port-zside-uuid", "", "", "user port uuid")
@@ -137,10 +196,29 @@ func init() {
 	connectionsCreateL2Cmd.Flags().Int64VarP(&createL2ConSpeed, "speed", "", 0, "connection speed (must be by 50 for MB ex.: 50, 100, 200, 500)")
 	connectionsCreateL2Cmd.Flags().StringVarP(&createL2ConSpeedUnit, "speed-unit", "", "", "connection speed unit MB, GB")
 
-	connectionsCreateL2Cmd.MarkFlagRequired("name")
-	connectionsCreateL2Cmd.MarkFlagRequired("port-uuid")
-	connectionsCreateL2Cmd.MarkFlagRequired("speed")
-	connectionsCreateL2Cmd.MarkFlagRequired("speed-unit")
+	connectionsCreateL2Cmd.Flags().BoolVar(&connectionWait, "wait", false, "wait until the connection is provisioned")
+	connectionsCreateL2Cmd.Flags().DurationVar(&connectionWaitTimeout, "timeout", 15*time.Minute, "maximum time to wait with --wait")
+
+	connectionsUpdateCmd.Flags().StringVarP(&updateConName, "name", "n", "", "new name for the connection")
+	connectionsUpdateCmd.Flags().Int64VarP(&updateConSpeed, "speed", "", 0, "new connection speed, must be offered by the seller profile")
+	connectionsUpdateCmd.Flags().StringVarP(&updateConSpeedUnit, "speed-unit", "", "", "connection speed unit MB, GB (defaults to the current unit)")
+	connectionsUpdateCmd.Flags().StringSliceVar(&updateConNotifications, "notifications", nil, "comma separated emails replacing the notification emails")
+	connectionsUpdateCmd.Flags().StringSliceVar(&updateConAddNotifications, "add-notification", nil, "email to add to the notification emails")
+	connectionsUpdateCmd.Flags().StringSliceVar(&updateConRemoveNotifications, "remove-notification", nil, "email to remove from the notification emails")
+	connectionsUpdateCmd.Flags().BoolVar(&connectionWait, "wait", false, "wait until the connection is provisioned again")
+	connectionsUpdateCmd.Flags().DurationVar(&connectionWaitTimeout, "timeout", 15*time.Minute, "maximum time to wait with --wait")
+
+	connectionsValidateAuthKeyCmd.Flags().StringVarP(&validateAuthKeySellerUUID, "seller-uuid", "", "", "seller profile uuid")
+	connectionsValidateAuthKeyCmd.Flags().StringVarP(&validateAuthKey, "auth-key", "", "", "service authorization key to validate")
+	connectionsValidateAuthKeyCmd.Flags().StringVarP(&validateAuthKeyMetro, "metro", "", "", "seller destination metro code (ex.: LD)")
+	connectionsValidateAuthKeyCmd.Flags().StringVarP(&validateAuthKeyRegion, "region", "", "", "seller destination region, when required by the seller (ex. AWS: eu-west-1)")
+	connectionsValidateAuthKeyCmd.MarkFlagRequired("seller-uuid")
+	connectionsValidateAuthKeyCmd.MarkFlagRequired("auth-key")
+	connectionsValidateAuthKeyCmd.MarkFlagRequired("metro")
+
+	connectionsCreateL2Cmd.Flags().StringVarP(&createFromFile, "from-file", "", "", "create every connection of a csv or yaml file (columns/keys are the API field names, eg.: primaryName)")
+	connectionsCreateL2Cmd.Flags().StringVarP(&createResultsFile, "results", "", "", "results file of --from-file (default <file>.results.csv)")
+	connectionsCreateL2Cmd.Flags().IntVarP(&createConcurrency, "concurrency", "", buyer.DefaultBulkConcurrency, "connections created at the same time with --from-file")
 
 }
 
@@ -154,18 +232,15 @@ func connectionsListCommand(cmd *cobra.Command, args []string) {
 	} else {
 		if connList.Count() > 0 {
 			if filterValues != "" {
-				filter := parseFilteringAttributes(filterValues)
-				connList.FilterItems(filter)
+				filter, err := parseFilter(filterValues)
+				if err != nil {
+					log.Fatal(err)
+				}
+				client.ResponseFilterExpression(connList, filter)
 
 			}
 
-			connections := connList.GetItems()
-			connRes, err := json.MarshalIndent(connections, "", "    ")
-			if err != nil {
-				log.Fatal("There was an error with json response:", err)
-			} else {
-				fmt.Println(string(connRes))
-			}
+			printList(connList, connectionColumns)
 
 		} else {
 			if metro != "" {
@@ -187,24 +262,162 @@ func connectionsGetByUUIDCommand(cmd *cobra.Command, args []string) {
 		if err != nil {
 			log.Fatal(err)
 		} else {
-			connRes, _ := json.MarshalIndent(conn, "", "    ")
-			fmt.Println(string(connRes))
+			printObject(conn.Payload, connectionColumns)
 		}
 	}
 }
 
 func connectionsDeleteByUUIDCommand(cmd *cobra.Command, args []string) {
+	uuids := append(append([]string{}, args...), deleteUUIDs...)
+	if len(uuids) == 0 && deleteFilter == "" && deleteFromFile == "" {
+		fmt.Println("Specify the connections to delete with uuids, --filter or --from-file")
+		return
+	}
+	conns, err := selectConnections(uuids, deleteFilter, deleteFromFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if conns.Count() == 0 {
+		fmt.Println("No connections matched")
+		return
+	}
+
+	printList(conns, connectionColumns)
+	if deleteDryRun {
+		// on stderr, keeping stdout parseable with -o json, yaml or csv
+		fmt.Fprintf(os.Stderr, "%d connections would be deleted\n", conns.Count())
+		return
+	}
+	if !deleteYes && !confirm(fmt.Sprintf("Delete %d connections?", conns.Count())) {
+		fmt.Println("Delete cancelled")
+		return
+	}
+
+	uuids = make([]string, conns.Count())
+	for i, conn := range conns.Items {
+		uuids[i] = conn.UUID
+	}
+
+	failed := 0
+	var deleted []string
+	for _, result := range ConnectionsAPIClient.DeleteByUUIDs(uuids, deleteConcurrency) {
+		if result.Err != nil {
+			failed++
+			fmt.Printf("Error deleting connection %s: %s\n", result.UUID, result.Err)
+			continue
+		}
+		fmt.Printf("Connection %s succesfully deleted\n", result.UUID)
+		deleted = append(deleted, result.UUID)
+	}
 
-	if deleteUUID != "" {
-		del, err := ConnectionsAPIClient.DeleteByUUID(deleteUUID)
+	if connectionWait && len(deleted) > 0 {
+		waitForConnections(deleted, buyer.ConnectionStatusDeprovisioned, buyer.ConnectionStatusNotFound)
+	}
+	if failed > 0 {
+		log.Fatalf("%d of %d connections failed to delete\n", failed, len(uuids))
+	}
+}
+
+// selectConnections returns the connections of uuids, of the uuids in file and those matching filter, without
+// duplicates. Unknown uuids are an error so nothing is deleted by mistake.
+func selectConnections(uuids []string, filter string, file string) (*buyer.ConnectionsResponse, error) {
+	if file != "" {
+		fileUUIDs, err := readUUIDs(file)
 		if err != nil {
-			fmt.Printf("Error deleting connection: %s\n", deleteUUID)
-		} else {
-			fmt.Printf("Connection %s succesfully deleted\n", del.Payload.PrimaryConnectionID)
-			//fmt.Println(del.Payload.Message)
+			return nil, fmt.Errorf("Error reading %s: %s", file, err)
+		}
+		uuids = append(uuids, fileUUIDs...)
+	}
+
+	selected := &buyer.ConnectionsResponse{}
+	seen := map[string]bool{}
+	for _, uuid := range uuids {
+		if uuid = strings.TrimSpace(uuid); uuid == "" || seen[uuid] {
+			continue
+		}
+		seen[uuid] = true
+
+		conn, err := ConnectionsAPIClient.GetByUUID(uuid)
+		if err != nil {
+			return nil, fmt.Errorf("Error getting connection %s: %s", uuid, err)
+		}
+		// the list and get models share the json representation
+		data, err := conn.Payload.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		item := &models.GetBuyerConResContent{}
+		if err := item.UnmarshalBinary(data); err != nil {
+			return nil, err
+		}
+		selected.Items = append(selected.Items, item)
+	}
+
+	if filter != "" {
+		expr, err := parseFilter(filter)
+		if err != nil {
+			return nil, err
+		}
+		connList, err := ConnectionsAPIClient.GetAllBuyerConnections(nil)
+		if err != nil {
+			return nil, err
+		}
+		client.ResponseFilterExpression(connList, expr)
+		for _, conn := range connList.Items {
+			if !seen[conn.UUID] {
+				seen[conn.UUID] = true
+				selected.Items = append(selected.Items, conn)
+			}
 		}
-	} else {
-		fmt.Println("Specify connection UUID to delete")
+	}
+
+	return selected, nil
+}
+
+func connectionsUpdateCommand(cmd *cobra.Command, args []string) {
+	uuid := args[0]
+
+	params := &buyer.UpdateConnectionParams{
+		Name:                updateConName,
+		Speed:               updateConSpeed,
+		SpeedUnit:           updateConSpeedUnit,
+		AddNotifications:    updateConAddNotifications,
+		RemoveNotifications: updateConRemoveNotifications,
+	}
+	if cmd.Flags().Changed("notifications") {
+		params.Notifications = updateConNotifications
+		if params.Notifications == nil {
+			params.Notifications = []string{}
+		}
+	}
+
+	_, err := ConnectionsAPIClient.UpdateConnection(uuid, params, SellerServicesAPIClient)
+	if err != nil {
+		switch t := err.(type) {
+		case *client.APIError:
+			for _, er := range t.Errors {
+				log.Printf("Error %s with message %s\n", er.ErrorCode, er.Message)
+			}
+			log.Fatalf("Error updating connection: %s\n", t.Error())
+		default:
+			log.Fatalf("Error updating connection: %s\n", err.Error())
+		}
+	}
+	fmt.Printf("Connection %s succesfully updated\n", uuid)
+
+	if connectionWait {
+		waitForConnections([]string{uuid}, buyer.ConnectionStatusProvisioned)
+	}
+}
+
+func connectionsValidateAuthKeyCommand(cmd *cobra.Command, args []string) {
+	res, err := ConnectionsAPIClient.ValidateAuthorizationKey(validateAuthKeySellerUUID, validateAuthKey, validateAuthKeyMetro, validateAuthKeyRegion)
+	if err != nil {
+		log.Fatalf("Error validating authorization key: %s\n", err)
+	}
+	fmt.Printf("Authorization key is valid for seller profile %s\n", validateAuthKeySellerUUID)
+	if res.Message != "" {
+		fmt.Println(res.Message)
 	}
 }
 
@@ -261,13 +474,23 @@ func connectionsCreateCloudCommand(cmd *cobra.Command, args []string) {
 
 	params.ProfileUUID = createL2ConSellerProfileUUID
 
+	// validate the key with the seller (aws account id, azure service key, google pairing key...)
+	_, err := ConnectionsAPIClient.ValidateAuthorizationKey(params.ProfileUUID, params.AuthorizationKey, params.SellerMetroCode, params.SellerRegion)
+	switch {
+	case errors.Is(err, buyer.ErrAuthorizationKeyValidationUnavailable):
+		log.Printf("Authorization key not validated: %s\n", err)
+	case err != nil:
+		log.Fatalf("Error validating authorization key: %s\n", err)
+	}
+
 	conn, err := ConnectionsAPIClient.CreateL2ConnectionToSellerProfile(params, SellerServicesAPIClient)
 	if err != nil {
 		switch t := err.(type) {
-		case *apiconnections.CreateConnectionUsingPOSTBadRequest:
-			for _, er := range t.Payload {
-				log.Fatalf("Error %s with message %s\n", er.ErrorCode, er.ErrorMessage)
+		case *client.APIError:
+			for _, er := range t.Errors {
+				log.Printf("Error %s with message %s\n", er.ErrorCode, er.Message)
 			}
+			log.Fatalf("Error creating connection: %s\n", t.Error())
 		default:
 			log.Fatalf("Error creating connection: %s\n", err.Error())
 		}
@@ -275,6 +498,10 @@ func connectionsCreateCloudCommand(cmd *cobra.Command, args []string) {
 	fmt.Printf("Connection %s succesfully created\n", conn.Payload.PrimaryConnectionID)
 
 	fmt.Printf("Connection %s succesfully created\n", conn.Payload.PrimaryConnectionID)
+
+	if connectionWait {
+		waitForConnections(createdConnections(conn.Payload), buyer.ConnectionStatusProvisioned)
+	}
 }
 
 // connectionsCreateCommand creates a L2 connection
@@ -287,6 +514,21 @@ func connectionsCreateCommand(cmd *cobra.Command, args []string) {
 	// vlanSTag - vlan source tag
 	// notifications email
 
+	if createFromFile != "" {
+		connectionsCreateFromFileCommand()
+		return
+	}
+
+	// required unless connections come from a file
+	for _, flag := range []string{"name", "port-uuid", "speed", "speed-unit"} {
+		if !cmd.Flags().Changed(flag) {
+			log.Fatalf("required flag \"%s\" not set\n", flag)
+		}
+	}
+
+	createL2ConPrimaryVlanSTag = resolveSTag(createL2ConPrimaryVlanSTagValue, createL2ConPrimaryPortUUID)
+	createL2ConSecondaryVlanSTag = resolveSTag(createL2ConSecondaryVlanSTagValue, createL2ConSecondaryPortUUID)
+
 	if createL2CSP {
 		connectionsCreateCloudCommand(cmd, args)
 		return
@@ -347,14 +589,79 @@ func connectionsCreateCommand(cmd *cobra.Command, args []string) {
 	conn, err := ConnectionsAPIClient.CreateL2Connection(params)
 	if err != nil {
 		switch t := err.(type) {
-		case *apiconnections.CreateConnectionUsingPOSTBadRequest:
-			for _, er := range t.Payload {
-				log.Fatalf("Error %s with message %s - %s - %s\n", er.ErrorCode, er.ErrorMessage, er.Property, er.MoreInfo)
+		case *client.APIError:
+			for _, er := range t.Errors {
+				log.Printf("Error %s with message %s - %s - %s\n", er.ErrorCode, er.Message, er.Property, er.MoreInfo)
 			}
+			log.Fatalf("Error creating connection: %s\n", t.Error())
 		default:
 			log.Fatalf("Error creating connection: %s\n", err.Error())
 		}
 	}
 	fmt.Printf("Connection %s succesfully created\n", conn.Payload.PrimaryConnectionID)
 
+	if connectionWait {
+		waitForConnections(createdConnections(conn.Payload), buyer.ConnectionStatusProvisioned)
+	}
+}
+
+// resolveSTag parses an S-Tag flag value, auto allocates the first vlan of --vlan-range free on port
+func resolveSTag(value string, port string) int64 {
+	if value == "" {
+		return 0
+	}
+	if value != "auto" {
+		tag, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			log.Fatalf("Invalid S-Tag %q, expected a vlan id or auto\n", value)
+		}
+		return tag
+	}
+
+	if port == "" {
+		log.Fatal("A port uuid is required to allocate a vlan")
+	}
+	min, max, err := parseVlanRange(createVlanRange)
+	if err != nil {
+		log.Fatal(err)
+	}
+	tag, err := ConnectionsAPIClient.AllocateSTag(port, min, max)
+	if err != nil {
+		log.Fatalf("Error allocating vlan: %s\n", err)
+	}
+	fmt.Printf("Using free vlan %d on port %s\n", tag, port)
+	return tag
+}
+
+// createdConnections returns the uuids of the primary and secondary (if any) connections created
+func createdConnections(payload *models.PostConnectionResponse) []string {
+	uuids := []string{payload.PrimaryConnectionID}
+	if payload.SecondaryConnectionID != "" {
+		uuids = append(uuids, payload.SecondaryConnectionID)
+	}
+	return uuids
+}
+
+// waitForConnections blocks until every connection reaches one of states or --timeout, shared by all the
+// connections, expires
+func waitForConnections(uuids []string, states ...string) {
+	ctx := context.Background()
+	if connectionWaitTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, connectionWaitTimeout)
+		defer cancel()
+	}
+
+	for _, uuid := range uuids {
+		fmt.Printf("Waiting for connection %s to be %s\n", uuid, strings.Join(states, " or "))
+		conn, err := ConnectionsAPIClient.WaitForConnectionStateWithContext(ctx, uuid, states, connectionWaitTimeout)
+		if err != nil {
+			log.Fatalf("Error waiting for connection %s: %s\n", uuid, err)
+		}
+		if conn != nil {
+			fmt.Printf("Connection %s is %s\n", uuid, conn.Status)
+		} else {
+			fmt.Printf("Connection %s no longer exists\n", uuid)
+		}
+	}
 }
@@ -23,8 +23,14 @@ type GlobalFlags struct {
 	PlaygroundToken        string
 	PlaygroundAPIEndpoint  string
 
-	Debug bool
-	NoSSL bool
+	Debug        bool
+	NoSSL        bool
+	NoTokenCache bool
+
+	RateLimit      float64
+	RateLimitBurst int
+
+	Output string
 
 	EquinixAPISecret string
 	EquinixAPIId     string
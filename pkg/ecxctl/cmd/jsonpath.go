@@ -0,0 +1,298 @@
+// Copyright © 2018 Juan Manuel Irigaray <jirigaray@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// jsonPath template for -o jsonpath, a subset of the kubectl syntax: text with {expressions} where an expression is
+// a path (.field, ['field'], [index], [*], .*), a quoted literal ({"\n"}) or a {range path}...{end} block
+//
+//	{[*].uuid}
+//	{range [*]}{.uuid}{"\t"}{.name}{"\n"}{end}
+type jsonPath struct {
+	nodes []jsonPathNode
+}
+
+// jsonPathNode text, expression or range of a jsonPath template
+type jsonPathNode struct {
+	text    string
+	path    []jsonPathStep
+	isPath  bool
+	isRange bool
+	nodes   []jsonPathNode
+}
+
+// jsonPathStep a step of a path, key selects a map key, index an array element and wildcard every child
+type jsonPathStep struct {
+	key      string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+// parseJSONPath parses a jsonpath template, a template without braces is a single expression
+func parseJSONPath(tmpl string) (*jsonPath, error) {
+	if !strings.Contains(tmpl, "{") {
+		tmpl = "{" + tmpl + "}"
+	}
+
+	root := &jsonPathNode{}
+	stack := []*jsonPathNode{root}
+	for len(tmpl) > 0 {
+		current := stack[len(stack)-1]
+
+		start := strings.Index(tmpl, "{")
+		if start < 0 {
+			current.nodes = append(current.nodes, jsonPathNode{text: tmpl})
+			break
+		}
+		if start > 0 {
+			current.nodes = append(current.nodes, jsonPathNode{text: tmpl[:start]})
+		}
+
+		end := closingBrace(tmpl, start)
+		if end < 0 {
+			return nil, fmt.Errorf("invalid jsonpath %q: unclosed {", tmpl[start:])
+		}
+		expr := strings.TrimSpace(tmpl[start+1 : end])
+		tmpl = tmpl[end+1:]
+
+		switch {
+		case expr == "end":
+			if len(stack) == 1 {
+				return nil, fmt.Errorf("invalid jsonpath: {end} without {range}")
+			}
+			stack = stack[:len(stack)-1]
+		case strings.HasPrefix(expr, "range "):
+			path, err := parseJSONPathSteps(strings.TrimSpace(strings.TrimPrefix(expr, "range ")))
+			if err != nil {
+				return nil, err
+			}
+			current.nodes = append(current.nodes, jsonPathNode{path: path, isRange: true})
+			stack = append(stack, &current.nodes[len(current.nodes)-1])
+		case strings.HasPrefix(expr, `"`) || strings.HasPrefix(expr, "'"):
+			text, err := unquoteJSONPath(expr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid jsonpath literal %s: %s", expr, err)
+			}
+			current.nodes = append(current.nodes, jsonPathNode{text: text})
+		default:
+			path, err := parseJSONPathSteps(expr)
+			if err != nil {
+				return nil, err
+			}
+			current.nodes = append(current.nodes, jsonPathNode{path: path, isPath: true})
+		}
+	}
+	if len(stack) > 1 {
+		return nil, fmt.Errorf("invalid jsonpath: {range} without {end}")
+	}
+
+	return &jsonPath{nodes: root.nodes}, nil
+}
+
+// closingBrace returns the index of the brace closing the one at start, skipping quoted text
+func closingBrace(s string, start int) int {
+	var quote byte
+	for i := start + 1; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0 && c == '\\':
+			i++
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote == 0 && (c == '"' || c == '\''):
+			quote = c
+		case quote == 0 && c == '}':
+			return i
+		}
+	}
+	return -1
+}
+
+func unquoteJSONPath(s string) (string, error) {
+	if strings.HasPrefix(s, "'") {
+		s = `"` + strings.Replace(strings.Trim(s, "'"), `"`, `\"`, -1) + `"`
+	}
+	return strconv.Unquote(s)
+}
+
+// parseJSONPathSteps parses a path expression
+func parseJSONPathSteps(expr string) ([]jsonPathStep, error) {
+	expr = strings.TrimPrefix(strings.TrimPrefix(expr, "$"), "@")
+	var steps []jsonPathStep
+	for len(expr) > 0 {
+		switch expr[0] {
+		case '.':
+			expr = expr[1:]
+			end := strings.IndexAny(expr, ".[")
+			if end < 0 {
+				end = len(expr)
+			}
+			name := expr[:end]
+			expr = expr[end:]
+			switch name {
+			case "":
+				// a lone "." is the current object
+			case "*":
+				steps = append(steps, jsonPathStep{wildcard: true})
+			default:
+				steps = append(steps, jsonPathStep{key: name})
+			}
+		case '[':
+			end := strings.Index(expr, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("invalid jsonpath %q: unclosed [", expr)
+			}
+			inner := strings.TrimSpace(expr[1:end])
+			expr = expr[end+1:]
+			switch {
+			case inner == "*":
+				steps = append(steps, jsonPathStep{wildcard: true})
+			case strings.HasPrefix(inner, "'") || strings.HasPrefix(inner, `"`):
+				key, err := unquoteJSONPath(inner)
+				if err != nil {
+					return nil, fmt.Errorf("invalid jsonpath key %s: %s", inner, err)
+				}
+				steps = append(steps, jsonPathStep{key: key})
+			default:
+				index, err := strconv.Atoi(inner)
+				if err != nil {
+					return nil, fmt.Errorf("invalid jsonpath index [%s]", inner)
+				}
+				steps = append(steps, jsonPathStep{index: index, isIndex: true})
+			}
+		default:
+			// allow paths without a leading dot, eg. {uuid}
+			expr = "." + expr
+		}
+	}
+	return steps, nil
+}
+
+// execute writes the template evaluated against data, missing keys produce no output
+func (p *jsonPath) execute(w io.Writer, data interface{}) error {
+	if err := executeJSONPathNodes(w, p.nodes, data); err != nil {
+		return err
+	}
+	if len(p.nodes) > 0 && p.nodes[len(p.nodes)-1].isPath {
+		// end the line when the template ends with an expression, so single values don't need {"\n"}
+		_, err := io.WriteString(w, "\n")
+		return err
+	}
+	return nil
+}
+
+func executeJSONPathNodes(w io.Writer, nodes []jsonPathNode, data interface{}) error {
+	for _, node := range nodes {
+		switch {
+		case node.isRange:
+			for _, value := range rangeValues(evalJSONPath(node.path, data)) {
+				if err := executeJSONPathNodes(w, node.nodes, value); err != nil {
+					return err
+				}
+			}
+		case node.isPath:
+			values := evalJSONPath(node.path, data)
+			formatted := make([]string, len(values))
+			for i, value := range values {
+				s, err := formatJSONPathValue(value)
+				if err != nil {
+					return err
+				}
+				formatted[i] = s
+			}
+			if _, err := io.WriteString(w, strings.Join(formatted, " ")); err != nil {
+				return err
+			}
+		default:
+			if _, err := io.WriteString(w, node.text); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// rangeValues iterates a single array result over its elements
+func rangeValues(values []interface{}) []interface{} {
+	if len(values) == 1 {
+		if list, ok := values[0].([]interface{}); ok {
+			return list
+		}
+	}
+	return values
+}
+
+// evalJSONPath returns the values found at path
+func evalJSONPath(path []jsonPathStep, data interface{}) []interface{} {
+	values := []interface{}{data}
+	for _, step := range path {
+		var next []interface{}
+		for _, value := range values {
+			switch t := value.(type) {
+			case map[string]interface{}:
+				if step.wildcard {
+					keys := make([]string, 0, len(t))
+					for k := range t {
+						keys = append(keys, k)
+					}
+					sort.Strings(keys)
+					for _, k := range keys {
+						next = append(next, t[k])
+					}
+				} else if v, ok := t[step.key]; ok && !step.isIndex {
+					next = append(next, v)
+				}
+			case []interface{}:
+				switch {
+				case step.wildcard:
+					next = append(next, t...)
+				case step.isIndex:
+					i := step.index
+					if i < 0 {
+						i += len(t)
+					}
+					if i >= 0 && i < len(t) {
+						next = append(next, t[i])
+					}
+				}
+			}
+		}
+		values = next
+	}
+	return values
+}
+
+// formatJSONPathValue prints strings and numbers as is, objects and arrays as json
+func formatJSONPathValue(v interface{}) (string, error) {
+	switch t := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return t, nil
+	case map[string]interface{}, []interface{}:
+		res, err := json.Marshal(t)
+		return string(res), err
+	}
+	return fmt.Sprint(v), nil
+}
@@ -0,0 +1,105 @@
+// Copyright © 2018 Juan Manuel Irigaray <jirigaray@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const jsonPathTestData = `[
+	{"uuid": "uuid-1", "name": "CONN-1", "speed": 50, "tags": ["a", "b"], "metadata": {"integration.id": "AWS-1", "team": "net"}},
+	{"uuid": "uuid-2", "name": "CONN-2", "speed": 1000, "tags": [], "metadata": {}}
+]`
+
+func newJSONPathTestData(t *testing.T) interface{} {
+	var data interface{}
+	if err := json.Unmarshal([]byte(jsonPathTestData), &data); err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestJSONPathExecute(t *testing.T) {
+	tests := []struct {
+		tmpl     string
+		expected string
+	}{
+		// single expressions end the line, a template without braces is an expression
+		{`{[*].uuid}`, "uuid-1 uuid-2\n"},
+		{`[*].name`, "CONN-1 CONN-2\n"},
+		{`{$[0].speed}`, "50\n"},
+		{`{[0].missing}`, "\n"},
+		{`{[0].tags}`, `["a","b"]` + "\n"},
+		{`{[0].metadata.*}`, "AWS-1 net\n"},
+		// templates ending with text or a range don't
+		{`{[0].uuid}{"\n"}`, "uuid-1\n"},
+		{`{[0].uuid} is {[0].name}.`, "uuid-1 is CONN-1."},
+		{`{range [*]}{.uuid}{"\t"}{.speed}{"\n"}{end}`, "uuid-1\t50\nuuid-2\t1000\n"},
+		// nested ranges, @ is the current value
+		{`{range [*]}{.name}:{range .tags[*]} {@}{end};{end}`, "CONN-1: a b;CONN-2:;"},
+		// quoted keys, with dots or braces
+		{`{[0]['metadata']['integration.id']}`, "AWS-1\n"},
+		{`{[0].metadata["integration.id"]}`, "AWS-1\n"},
+		{`{'}'}{"{"}`, "}{"},
+		// negative indexes count from the end, out of range ones produce nothing
+		{`{[-1].uuid}`, "uuid-2\n"},
+		{`{[0].tags[-2]}`, "a\n"},
+		{`{[-3].uuid}`, "\n"},
+	}
+
+	data := newJSONPathTestData(t)
+	for _, test := range tests {
+		path, err := parseJSONPath(test.tmpl)
+		if err != nil {
+			t.Errorf("%s: unexpected error %s", test.tmpl, err)
+			continue
+		}
+		var buf bytes.Buffer
+		if err := path.execute(&buf, data); err != nil {
+			t.Errorf("%s: unexpected error %s", test.tmpl, err)
+			continue
+		}
+		if buf.String() != test.expected {
+			t.Errorf("%s: expected %q, received %q", test.tmpl, test.expected, buf.String())
+		}
+	}
+}
+
+func TestParseJSONPathErrors(t *testing.T) {
+	tests := []struct {
+		tmpl string
+		err  string
+	}{
+		{`{.uuid`, "unclosed {"},
+		{`{range [*]}{"}"`, "unclosed {"},
+		{`{range [*]}{.uuid}`, "{range} without {end}"},
+		{`{range [*]}{range .tags}{end}`, "{range} without {end}"},
+		{`{.uuid}{end}`, "{end} without {range}"},
+		{`{[0}`, "unclosed ["},
+		{`{[first]}`, "invalid jsonpath index [first]"},
+		{`{["\q"]}`, "invalid jsonpath key"},
+		{`{"\q"}`, "invalid jsonpath literal"},
+	}
+
+	for _, test := range tests {
+		_, err := parseJSONPath(test.tmpl)
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: expected error containing %q, received %v", test.tmpl, test.err, err)
+		}
+	}
+}
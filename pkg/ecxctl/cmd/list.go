@@ -0,0 +1,96 @@
+// Copyright © 2018 Juan Manuel Irigaray <jirigaray@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	client "github.com/jxoir/equinix-tools/pkg/ecxlib/api/client"
+	"github.com/spf13/cobra"
+)
+
+// flags shared by list commands, only one command runs per invocation
+var listSortBy string
+var listFields string
+
+// addListFlags adds --sort-by and --fields to a list command
+func addListFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&listSortBy, "sort-by", "", "sort by field, append ',desc' for descending order (eg.: speed,desc)")
+	cmd.Flags().StringVar(&listFields, "fields", "", "comma separated list of fields to output (eg.: uuid,name,status,speed)")
+}
+
+// parseSortBy splits the --sort-by value in field and order
+func parseSortBy(str string) (string, bool, error) {
+	parts := strings.Split(str, ",")
+	field := strings.TrimSpace(parts[0])
+	if field == "" || len(parts) > 2 {
+		return "", false, fmt.Errorf("invalid --sort-by %q, expected <field>[,asc|desc]", str)
+	}
+	if len(parts) == 1 {
+		return field, false, nil
+	}
+	switch strings.ToLower(strings.TrimSpace(parts[1])) {
+	case "asc":
+		return field, false, nil
+	case "desc":
+		return field, true, nil
+	}
+	return "", false, fmt.Errorf("invalid --sort-by order %q, expected asc or desc", parts[1])
+}
+
+// parseFields splits the --fields value
+func parseFields(str string) []string {
+	var fields []string
+	for _, field := range strings.Split(str, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// printList sorts the list items as requested by --sort-by and prints them in the --output format, columns are
+// the table columns of the resource
+func printList(list client.ECXAPIResponse, columns []column) {
+	if listSortBy != "" {
+		field, desc, err := parseSortBy(listSortBy)
+		if err != nil {
+			log.Fatal(err)
+		}
+		client.ResponseSort(list, field, desc)
+	}
+
+	p, err := newPrinter(globalFlags.Output)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := p.PrintList(os.Stdout, list, columns); err != nil {
+		log.Fatal("There was an error printing the response:", err)
+	}
+}
+
+// printObject prints a single resource in the --output format
+func printObject(obj interface{}, columns []column) {
+	p, err := newPrinter(globalFlags.Output)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := p.PrintObject(os.Stdout, obj, columns); err != nil {
+		log.Fatal("There was an error printing the response:", err)
+	}
+}
@@ -15,8 +15,6 @@
 package cmd
 
 import (
-	"encoding/json"
-	"fmt"
 	"log"
 
 	"github.com/spf13/cobra"
@@ -38,6 +36,7 @@ var metrosListCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(metrosCmd)
 	metrosCmd.AddCommand(metrosListCmd)
+	addListFlags(metrosListCmd)
 
 }
 
@@ -47,14 +46,7 @@ func metrosListCommand(cmd *cobra.Command, args []string) {
 		log.Fatal(err)
 	} else {
 		if metrosList != nil {
-			metros := metrosList.Payload
-			metrosRes, err := json.MarshalIndent(metros, "", "    ")
-			if err != nil {
-				log.Fatal("There was an error with json response:", err)
-			} else {
-				fmt.Println(string(metrosRes))
-			}
-
+			printList(metrosList, metroColumns)
 		}
 	}
 }
@@ -15,13 +15,43 @@
 package cmd
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"os"
+	"strconv"
+	"strings"
 
+	"github.com/jxoir/equinix-tools/pkg/ecxlib/api/buyer"
+	client "github.com/jxoir/equinix-tools/pkg/ecxlib/api/client"
+	"github.com/jxoir/go-ecxfabric/buyer/models"
 	"github.com/spf13/cobra"
 )
 
+// defaultVlanRange every vlan id valid for a connection
+var defaultVlanRange = fmt.Sprintf("%d-%d", buyer.MinVlan, buyer.MaxVlan)
+
+// flags of ports list
+var portsMetro string
+var portsStatus string
+var portsBandwidth int64
+
+// flags of ports vlans
+var portVlansFree bool
+var portVlansRange string
+
+// flags of ports utilization
+var portUtilizationFilter string
+var portUtilizationWarning float64
+var portUtilizationCritical float64
+
+// exit codes of ports utilization, as expected by nagios style monitoring checks
+var utilizationExitCodes = map[string]int{
+	buyer.UtilizationOK:       0,
+	buyer.UtilizationWarning:  1,
+	buyer.UtilizationCritical: 2,
+}
+
 // metrosCmd represents the metros command
 var portsCmd = &cobra.Command{
 	Use:   "ports",
@@ -34,27 +64,175 @@ var portsListCmd = &cobra.Command{
 	Run:   portsListCommand,
 }
 
+var portsGetCmd = &cobra.Command{
+	Use:   "get <uuid|name>",
+	Short: "get a user port by uuid or name",
+	Args:  cobra.ExactArgs(1),
+	Run:   portsGetCommand,
+}
+
+var portsConnectionsCmd = &cobra.Command{
+	Use:   "connections <uuid|name>",
+	Short: "list the connections riding a port with their vlans and seller destinations",
+	Args:  cobra.ExactArgs(1),
+	Run:   portsConnectionsCommand,
+}
+
+var portsVlansCmd = &cobra.Command{
+	Use:   "vlans <port-uuid>",
+	Short: "list vlans used on a port by the buyer connections, or the free ones with --free",
+	Args:  cobra.ExactArgs(1),
+	Run:   portsVlansCommand,
+}
+
+var portsUtilizationCmd = &cobra.Command{
+	Use:   "utilization",
+	Short: "report the bandwidth provisioned on every port against its capacity",
+	Long: `Report per port the bandwidth provisioned by the buyer connections against the port capacity (in Mbps),
+the number of connections, the utilization percentage, the oversubscription ratio and the headroom left.
+
+With --warning and/or --critical (utilization percentages) every port gets a state and the command exits with
+1 if any port is above the warning threshold and 2 if any is above the critical one, 0 otherwise.`,
+	Run: portsUtilizationCommand,
+}
+
 func init() {
 	rootCmd.AddCommand(portsCmd)
 	portsCmd.AddCommand(portsListCmd)
+	addListFlags(portsListCmd)
+	portsListCmd.Flags().StringVar(&portsMetro, "metro", "", "Filter metro code (ex.: LD)")
+	portsListCmd.Flags().StringVar(&portsStatus, "status", "", "Filter provision status (ex.: PROVISIONED)")
+	portsListCmd.Flags().Int64Var(&portsBandwidth, "bandwidth", 0, "Filter total bandwidth in bps (ex.: 10000000000)")
+
+	portsCmd.AddCommand(portsGetCmd)
 
+	portsCmd.AddCommand(portsConnectionsCmd)
+	addListFlags(portsConnectionsCmd)
+
+	portsCmd.AddCommand(portsVlansCmd)
+	portsVlansCmd.Flags().BoolVar(&portVlansFree, "free", false, "list the free vlan ranges instead of the used vlans")
+	portsVlansCmd.Flags().StringVar(&portVlansRange, "range", defaultVlanRange, "vlan range considered by --free")
+
+	portsCmd.AddCommand(portsUtilizationCmd)
+	addListFlags(portsUtilizationCmd)
+	portsUtilizationCmd.Flags().StringVarP(&portUtilizationFilter, "filter", "f", "", "Filter expression (eg.: 'metroCode=LD and utilizationPercent>50')")
+	portsUtilizationCmd.Flags().Float64Var(&portUtilizationWarning, "warning", 0, "utilization percentage of a port that exits with code 1")
+	portsUtilizationCmd.Flags().Float64Var(&portUtilizationCritical, "critical", 0, "utilization percentage of a port that exits with code 2")
 }
 
 func portsListCommand(cmd *cobra.Command, args []string) {
-	portsList, err := PortsAPIClient.GetAllPorts()
+	portsList, err := PortsAPIClient.GetPorts(&buyer.PortFilters{
+		MetroCode:       portsMetro,
+		ProvisionStatus: portsStatus,
+		TotalBandwidth:  portsBandwidth,
+	})
 	if err != nil {
 		log.Fatal(err)
 	} else {
 		if portsList != nil {
+			printList(portsList, portColumns)
+		}
+	}
+}
+
+func portsGetCommand(cmd *cobra.Command, args []string) {
+	port, err := getPort(args[0])
+	if err != nil {
+		log.Fatal(err)
+	}
+	printObject(port, portColumns)
+}
+
+func portsConnectionsCommand(cmd *cobra.Command, args []string) {
+	port, err := getPort(args[0])
+	if err != nil {
+		log.Fatal(err)
+	}
+	conns, err := ConnectionsAPIClient.GetPortConnections(port.UUID)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if conns.Count() == 0 {
+		fmt.Printf("No connections found on port %s\n", port.Name)
+		return
+	}
+	printList(conns, portConnectionColumns)
+}
+
+// getPort returns the port with uuid or, if there is none, named as it
+func getPort(uuidOrName string) (*models.UserPortResObj, error) {
+	port, err := PortsAPIClient.GetPortByUUID(uuidOrName)
+	if errors.Is(err, buyer.ErrPortNotFound) {
+		return PortsAPIClient.GetPortByName(uuidOrName)
+	}
+	return port, err
+}
+
+func portsUtilizationCommand(cmd *cobra.Command, args []string) {
+	report, err := PortsAPIClient.GetPortUtilization(ConnectionsAPIClient)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if portUtilizationFilter != "" {
+		filter, err := parseFilter(portUtilizationFilter)
+		if err != nil {
+			log.Fatal(err)
+		}
+		client.ResponseFilterExpression(report, filter)
+	}
 
-			ports := portsList.Payload
-			portsRes, err := json.MarshalIndent(ports, "", "    ")
-			if err != nil {
-				log.Fatal("There was an error with json response:", err)
-			} else {
-				fmt.Println(string(portsRes))
-			}
+	state := buyer.UtilizationOK
+	if portUtilizationWarning > 0 || portUtilizationCritical > 0 {
+		state = report.CheckThresholds(portUtilizationWarning, portUtilizationCritical)
+	}
+	printList(report, portUtilizationColumns)
+	os.Exit(utilizationExitCodes[state])
+}
+
+// vlanRange range of consecutive free vlans
+type vlanRange struct {
+	From  int64 `json:"from"`
+	To    int64 `json:"to"`
+	Count int64 `json:"count"`
+}
+
+func portsVlansCommand(cmd *cobra.Command, args []string) {
+	vlans, err := ConnectionsAPIClient.GetPortVlans(args[0])
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if !portVlansFree {
+		printList(vlans, portVlanColumns)
+		return
+	}
+
+	min, max, err := parseVlanRange(portVlansRange)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ranges := &itemList{}
+	for _, tag := range vlans.FreeSTags(min, max) {
+		if n := len(ranges.items); n > 0 && ranges.items[n-1].(*vlanRange).To == tag-1 {
+			last := ranges.items[n-1].(*vlanRange)
+			last.To, last.Count = tag, last.Count+1
+			continue
+		}
+		ranges.items = append(ranges.items, &vlanRange{From: tag, To: tag, Count: 1})
+	}
+	printList(ranges, vlanRangeColumns)
+}
 
+// parseVlanRange parses a from-to vlan range
+func parseVlanRange(str string) (int64, int64, error) {
+	parts := strings.Split(str, "-")
+	if len(parts) == 2 {
+		min, minErr := strconv.ParseInt(strings.TrimSpace(parts[0]), 10, 64)
+		max, maxErr := strconv.ParseInt(strings.TrimSpace(parts[1]), 10, 64)
+		if minErr == nil && maxErr == nil && min >= buyer.MinVlan && max <= buyer.MaxVlan && min <= max {
+			return min, max, nil
 		}
 	}
+	return 0, 0, fmt.Errorf("invalid vlan range %q, expected from-to within %s", str, defaultVlanRange)
 }
@@ -0,0 +1,417 @@
+// Copyright © 2018 Juan Manuel Irigaray <jirigaray@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+
+	client "github.com/jxoir/equinix-tools/pkg/ecxlib/api/client"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// column of the table and csv outputs, Field is a json field path as used by --filter
+type column struct {
+	Header string
+	Field  string
+	// Wide columns are only printed by -o wide (and csv)
+	Wide bool
+}
+
+var connectionColumns = []column{
+	{Header: "UUID", Field: "uuid"},
+	{Header: "NAME", Field: "name"},
+	{Header: "METRO", Field: "metroCode"},
+	{Header: "STATUS", Field: "status"},
+	{Header: "SPEED", Field: "speed"},
+	{Header: "UNIT", Field: "speedUnit"},
+	{Header: "PORT", Field: "portName"},
+	{Header: "VLAN", Field: "vlanSTag"},
+	{Header: "SELLER", Field: "sellerServiceName", Wide: true},
+	{Header: "SELLER METRO", Field: "sellerMetroCode", Wide: true},
+	{Header: "REDUNDANCY", Field: "redundancyType", Wide: true},
+	{Header: "CREATED", Field: "createdDate", Wide: true},
+}
+
+var portColumns = []column{
+	{Header: "UUID", Field: "uuid"},
+	{Header: "NAME", Field: "name"},
+	{Header: "METRO", Field: "metroCode"},
+	{Header: "IBX", Field: "ibx"},
+	{Header: "STATUS", Field: "provisionStatus"},
+	{Header: "BANDWIDTH", Field: "totalBandwidth"},
+	{Header: "ENCAPSULATION", Field: "encapsulation", Wide: true},
+	{Header: "DEVICE", Field: "device", Wide: true},
+	{Header: "LAG", Field: "lag", Wide: true},
+}
+
+var portConnectionColumns = []column{
+	{Header: "UUID", Field: "uuid"},
+	{Header: "NAME", Field: "name"},
+	{Header: "STATUS", Field: "status"},
+	{Header: "SPEED", Field: "speed"},
+	{Header: "UNIT", Field: "speedUnit"},
+	{Header: "VLAN", Field: "vlanSTag"},
+	{Header: "SELLER", Field: "sellerServiceName"},
+	{Header: "SELLER METRO", Field: "sellerMetroCode"},
+	{Header: "Z-SIDE PORT", Field: "zSidePortName", Wide: true},
+	{Header: "Z-SIDE S-TAG", Field: "zSideVlanSTag", Wide: true},
+	{Header: "Z-SIDE C-TAG", Field: "zSideVlanCTag", Wide: true},
+	{Header: "REDUNDANCY", Field: "redundancyType", Wide: true},
+}
+
+var portVlanColumns = []column{
+	{Header: "S-TAG", Field: "sTag"},
+	{Header: "C-TAG", Field: "cTag"},
+	{Header: "CONNECTION", Field: "connectionUUID"},
+	{Header: "NAME", Field: "connectionName"},
+	{Header: "STATUS", Field: "status"},
+}
+
+var portUtilizationColumns = []column{
+	{Header: "UUID", Field: "portUUID"},
+	{Header: "NAME", Field: "portName"},
+	{Header: "METRO", Field: "metroCode"},
+	{Header: "CAPACITY", Field: "capacityMbps"},
+	{Header: "PROVISIONED", Field: "provisionedMbps"},
+	{Header: "CONNECTIONS", Field: "connections"},
+	{Header: "UTILIZATION %", Field: "utilizationPercent"},
+	{Header: "HEADROOM", Field: "headroomMbps"},
+	{Header: "STATE", Field: "state"},
+	{Header: "OVERSUBSCRIPTION", Field: "oversubscription", Wide: true},
+}
+
+var vlanRangeColumns = []column{
+	{Header: "FROM", Field: "from"},
+	{Header: "TO", Field: "to"},
+	{Header: "COUNT", Field: "count"},
+}
+
+var metroColumns = []column{
+	{Header: "CODE", Field: "code"},
+	{Header: "NAME", Field: "name"},
+	{Header: "REGION", Field: "region"},
+	{Header: "CLOUD REACH", Field: "cloudReach", Wide: true},
+}
+
+var routingInstanceColumns = []column{
+	{Header: "UUID", Field: "uuid"},
+	{Header: "NAME", Field: "name"},
+	{Header: "METRO", Field: "metroCode"},
+	{Header: "STATE", Field: "state"},
+	{Header: "ASN", Field: "asn"},
+	{Header: "ROUTE TYPE", Field: "routeType"},
+	{Header: "EQUINIX ASN", Field: "equinixAsn", Wide: true},
+	{Header: "NOTIFICATIONS", Field: "notificationEmails", Wide: true},
+	{Header: "CREATED", Field: "createdDate", Wide: true},
+}
+
+var sellerProfileColumns = []column{
+	{Header: "UUID", Field: "uuid"},
+	{Header: "NAME", Field: "name"},
+	{Header: "ORGANIZATION", Field: "organizationName"},
+	{Header: "ENCAPSULATION", Field: "profileEncapsulation"},
+	{Header: "METROS", Field: "metros.code", Wide: true},
+	{Header: "SPEEDS", Field: "speedBands.speed", Wide: true},
+	{Header: "REDUNDANCY", Field: "requiredRedundancy", Wide: true},
+}
+
+var sellerServiceColumns = []column{
+	{Header: "UUID", Field: "uuid"},
+	{Header: "NAME", Field: "name"},
+	{Header: "METROS", Field: "metros.code"},
+	{Header: "SPEEDS", Field: "speedBands.speed", Wide: true},
+	{Header: "DESCRIPTION", Field: "description", Wide: true},
+}
+
+// printer prints resources in one of the --output formats
+type printer interface {
+	// PrintObject prints a single resource
+	PrintObject(w io.Writer, obj interface{}, columns []column) error
+	// PrintList prints the items of a list
+	PrintList(w io.Writer, list client.ECXAPIResponse, columns []column) error
+}
+
+// newPrinter returns the printer for format, an empty format prints a table on terminals and json otherwise
+func newPrinter(format string) (printer, error) {
+	if format == "" {
+		if isTerminal(os.Stdout) {
+			format = "table"
+		} else {
+			format = "json"
+		}
+	}
+
+	name, arg := format, ""
+	if i := strings.Index(format, "="); i >= 0 {
+		name, arg = format[:i], format[i+1:]
+	}
+
+	switch name {
+	case "table":
+		return &tablePrinter{}, nil
+	case "wide":
+		return &tablePrinter{wide: true}, nil
+	case "csv":
+		return &csvPrinter{}, nil
+	case "json":
+		return &valuePrinter{printValue: printJSON, raw: true}, nil
+	case "yaml":
+		return &valuePrinter{printValue: printYAML}, nil
+	case "jsonpath":
+		if arg == "" {
+			return nil, fmt.Errorf("jsonpath output requires an expression (eg.: -o jsonpath='{[*].uuid}')")
+		}
+		path, err := parseJSONPath(arg)
+		if err != nil {
+			return nil, err
+		}
+		return &valuePrinter{printValue: path.execute}, nil
+	case "go-template":
+		if arg == "" {
+			return nil, fmt.Errorf("go-template output requires a template (eg.: -o go-template='{{range .}}{{.uuid}}{{end}}')")
+		}
+		tmpl, err := template.New("output").Parse(arg)
+		if err != nil {
+			return nil, err
+		}
+		return &valuePrinter{printValue: func(w io.Writer, v interface{}) error {
+			return tmpl.Execute(w, v)
+		}}, nil
+	}
+
+	return nil, fmt.Errorf("unknown output format %q, expected one of table, wide, json, yaml, csv, jsonpath=..., go-template=...", format)
+}
+
+// isTerminal returns true if f is a character device
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// valuePrinter prints the items (or object) as a single document, values are converted to their json representation
+// so yaml and templates see the json field names, unless raw is set
+type valuePrinter struct {
+	printValue func(w io.Writer, v interface{}) error
+	raw        bool
+}
+
+// PrintObject prints obj
+func (p *valuePrinter) PrintObject(w io.Writer, obj interface{}, columns []column) error {
+	return p.print(w, obj)
+}
+
+// PrintList prints the list items as an array, projected to --fields if set
+func (p *valuePrinter) PrintList(w io.Writer, list client.ECXAPIResponse, columns []column) error {
+	var items interface{} = list.GetItems()
+	if fields := parseFields(listFields); len(fields) > 0 {
+		items = client.ResponseFields(list, fields)
+	}
+	return p.print(w, items)
+}
+
+func (p *valuePrinter) print(w io.Writer, v interface{}) error {
+	if !p.raw {
+		var err error
+		if v, err = toGeneric(v); err != nil {
+			return err
+		}
+	}
+	return p.printValue(w, v)
+}
+
+func printJSON(w io.Writer, v interface{}) error {
+	res, err := json.MarshalIndent(v, "", "    ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(res))
+	return err
+}
+
+func printYAML(w io.Writer, v interface{}) error {
+	res, err := yaml.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(res)
+	return err
+}
+
+// toGeneric converts v to its json representation made of maps, slices and scalars, integers are kept as int64
+func toGeneric(v interface{}) (interface{}, error) {
+	res, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(res))
+	dec.UseNumber()
+	var generic interface{}
+	if err := dec.Decode(&generic); err != nil {
+		return nil, err
+	}
+	return normalizeNumbers(generic), nil
+}
+
+// normalizeNumbers replaces json.Number with int64 or float64 so every output format prints them as numbers
+func normalizeNumbers(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, e := range t {
+			t[k] = normalizeNumbers(e)
+		}
+	case []interface{}:
+		for i, e := range t {
+			t[i] = normalizeNumbers(e)
+		}
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i
+		}
+		f, _ := t.Float64()
+		return f
+	}
+	return v
+}
+
+// itemList ECXAPIResponse over any items, objects are printed as one item lists (one row tables)
+type itemList struct {
+	items []interface{}
+}
+
+func (s *itemList) GetItems() []interface{}               { return s.items }
+func (s *itemList) AppendItems(items []interface{})       { s.items = append(s.items, items...) }
+func (s *itemList) SetItems(items []interface{})          { s.items = items }
+func (s *itemList) FilterItems(filters map[string]string) { client.ResponseFilter(s, filters) }
+func (s *itemList) Count() int                            { return len(s.items) }
+
+// tableColumns returns the columns to print, --fields replaces the resource columns
+func tableColumns(columns []column, wide bool) []column {
+	if fields := parseFields(listFields); len(fields) > 0 {
+		selected := make([]column, len(fields))
+		for i, field := range fields {
+			selected[i] = column{Header: strings.ToUpper(field), Field: field}
+		}
+		return selected
+	}
+
+	var selected []column
+	for _, c := range columns {
+		if !c.Wide || wide {
+			selected = append(selected, c)
+		}
+	}
+	return selected
+}
+
+// tableRows returns the formatted values of columns for every item of list
+func tableRows(list client.ECXAPIResponse, columns []column) [][]string {
+	fields := make([]string, len(columns))
+	for i, c := range columns {
+		fields[i] = c.Field
+	}
+
+	projected := client.ResponseFields(list, fields)
+	rows := make([][]string, len(projected))
+	for i, item := range projected {
+		row := make([]string, len(item.Values))
+		for j, value := range item.Values {
+			row[j] = formatCell(value)
+		}
+		rows[i] = row
+	}
+	return rows
+}
+
+// formatCell formats a value for table and csv outputs, lists are comma separated
+func formatCell(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case []interface{}:
+		values := make([]string, len(t))
+		for i, e := range t {
+			values[i] = formatCell(e)
+		}
+		return strings.Join(values, ",")
+	case []string:
+		return strings.Join(t, ",")
+	}
+	return fmt.Sprint(v)
+}
+
+// tablePrinter prints aligned columns with a header
+type tablePrinter struct {
+	wide bool
+}
+
+// PrintObject prints obj as a one row table
+func (p *tablePrinter) PrintObject(w io.Writer, obj interface{}, columns []column) error {
+	return p.PrintList(w, &itemList{items: []interface{}{obj}}, columns)
+}
+
+// PrintList prints a row per item
+func (p *tablePrinter) PrintList(w io.Writer, list client.ECXAPIResponse, columns []column) error {
+	columns = tableColumns(columns, p.wide)
+	tw := tabwriter.NewWriter(w, 0, 4, 3, ' ', 0)
+
+	headers := make([]string, len(columns))
+	for i, c := range columns {
+		headers[i] = c.Header
+	}
+	fmt.Fprintln(tw, strings.Join(headers, "\t"))
+	for _, row := range tableRows(list, columns) {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+// csvPrinter prints every column (including wide ones) with the field paths as header
+type csvPrinter struct{}
+
+// PrintObject prints obj as a single record
+func (p *csvPrinter) PrintObject(w io.Writer, obj interface{}, columns []column) error {
+	return p.PrintList(w, &itemList{items: []interface{}{obj}}, columns)
+}
+
+// PrintList prints a record per item
+func (p *csvPrinter) PrintList(w io.Writer, list client.ECXAPIResponse, columns []column) error {
+	columns = tableColumns(columns, true)
+	cw := csv.NewWriter(w)
+
+	headers := make([]string, len(columns))
+	for i, c := range columns {
+		headers[i] = c.Field
+	}
+	if err := cw.Write(headers); err != nil {
+		return err
+	}
+	if err := cw.WriteAll(tableRows(list, columns)); err != nil {
+		return err
+	}
+	return cw.Error()
+}
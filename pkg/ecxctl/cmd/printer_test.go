@@ -0,0 +1,142 @@
+// Copyright © 2018 Juan Manuel Irigaray <jirigaray@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"testing"
+)
+
+type printerTestItem struct {
+	UUID  string   `json:"uuid"`
+	Name  string   `json:"name"`
+	Speed int64    `json:"speed"`
+	Tags  []string `json:"tags,omitempty"`
+}
+
+var printerTestColumns = []column{
+	{Header: "UUID", Field: "uuid"},
+	{Header: "NAME", Field: "name"},
+	{Header: "SPEED", Field: "speed"},
+	{Header: "TAGS", Field: "tags", Wide: true},
+}
+
+func newPrinterTestList() *itemList {
+	return &itemList{items: []interface{}{
+		&printerTestItem{UUID: "uuid-1", Name: "CONN-1", Speed: 50, Tags: []string{"a", "b"}},
+		&printerTestItem{UUID: "uuid-2", Name: "CONN-22", Speed: 1000},
+	}}
+}
+
+func TestPrinters(t *testing.T) {
+	tests := []struct {
+		format   string
+		fields   string
+		expected string
+	}{
+		{
+			format: "table",
+			expected: "UUID     NAME      SPEED\n" +
+				"uuid-1   CONN-1    50\n" +
+				"uuid-2   CONN-22   1000\n",
+		},
+		{
+			format: "wide",
+			expected: "UUID     NAME      SPEED   TAGS\n" +
+				"uuid-1   CONN-1    50      a,b\n" +
+				"uuid-2   CONN-22   1000    \n",
+		},
+		{
+			// --fields replaces the columns, wide or not
+			format: "table",
+			fields: "name, tags",
+			expected: "NAME      TAGS\n" +
+				"CONN-1    a,b\n" +
+				"CONN-22   \n",
+		},
+		{
+			format:   "csv",
+			expected: "uuid,name,speed,tags\nuuid-1,CONN-1,50,\"a,b\"\nuuid-2,CONN-22,1000,\n",
+		},
+		{
+			format:   "csv",
+			fields:   "speed",
+			expected: "speed\n50\n1000\n",
+		},
+		{
+			format:   "yaml",
+			fields:   "uuid",
+			expected: "- uuid: uuid-1\n- uuid: uuid-2\n",
+		},
+		{
+			format:   "json",
+			fields:   "name,speed",
+			expected: "[\n    {\n        \"name\": \"CONN-1\",\n        \"speed\": 50\n    },\n    {\n        \"name\": \"CONN-22\",\n        \"speed\": 1000\n    }\n]\n",
+		},
+		{
+			format:   "jsonpath={[*].uuid}",
+			expected: "uuid-1 uuid-2\n",
+		},
+		{
+			format:   `go-template={{range .}}{{.name}}={{.speed}} {{end}}`,
+			expected: "CONN-1=50 CONN-22=1000 ",
+		},
+	}
+
+	defer func(fields string) { listFields = fields }(listFields)
+	for _, test := range tests {
+		listFields = test.fields
+		p, err := newPrinter(test.format)
+		if err != nil {
+			t.Errorf("%s: unexpected error %s", test.format, err)
+			continue
+		}
+		var buf bytes.Buffer
+		if err := p.PrintList(&buf, newPrinterTestList(), printerTestColumns); err != nil {
+			t.Errorf("%s: unexpected error %s", test.format, err)
+			continue
+		}
+		if buf.String() != test.expected {
+			t.Errorf("%s --fields %q: expected\n%q\nreceived\n%q", test.format, test.fields, test.expected, buf.String())
+		}
+	}
+}
+
+func TestPrintObject(t *testing.T) {
+	defer func(fields string) { listFields = fields }(listFields)
+	listFields = ""
+
+	p, err := newPrinter("table")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	item := &printerTestItem{UUID: "uuid-1", Name: "CONN-1", Speed: 50}
+	if err := p.PrintObject(&buf, item, printerTestColumns); err != nil {
+		t.Fatal(err)
+	}
+	expected := "UUID     NAME     SPEED\nuuid-1   CONN-1   50\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, received %q", expected, buf.String())
+	}
+}
+
+func TestNewPrinterErrors(t *testing.T) {
+	for _, format := range []string{"xml", "jsonpath", "jsonpath={.uuid", "go-template", "go-template={{.uuid"} {
+		if _, err := newPrinter(format); err == nil {
+			t.Errorf("%s: expected error", format)
+		}
+	}
+}
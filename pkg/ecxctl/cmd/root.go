@@ -16,6 +16,7 @@ package cmd
 
 import (
 	"fmt"
+	"log"
 	"os"
 	"regexp"
 
@@ -93,6 +94,10 @@ func init() {
 	rootCmd.PersistentFlags().StringVar(&globalFlags.PlaygroundAPIEndpoint, "playground-endpoint", "playgroundapi.equinix.com", "Equinix Developer Playground endpoint")
 
 	rootCmd.PersistentFlags().BoolVar(&globalFlags.NoSSL, "ignore-ssl", false, "Don't verify server SSL **INSECURE**")
+	rootCmd.PersistentFlags().Float64Var(&globalFlags.RateLimit, "rate-limit", 0, "maximum ECX API requests per second (0 unlimited)")
+	rootCmd.PersistentFlags().IntVar(&globalFlags.RateLimitBurst, "rate-limit-burst", 1, "ECX API requests allowed in a burst above rate-limit")
+	rootCmd.PersistentFlags().StringVarP(&globalFlags.Output, "output", "o", "", "output format: table, wide, json, yaml, csv, jsonpath=..., go-template=... (default table on terminals, json otherwise)")
+	rootCmd.PersistentFlags().BoolVar(&globalFlags.NoTokenCache, "no-token-cache", false, "Don't reuse or store API tokens between invocations")
 
 	rootCmd.PersistentFlags().StringVar(&globalFlags.EcxAPIHost, "ecx-api-host", os.Getenv("ECX_API_HOST"), "ECX API endpoint")
 	rootCmd.PersistentFlags().StringVar(&globalFlags.UserName, "user", os.Getenv("ECX_API_USER"), "portal username")
@@ -162,6 +167,19 @@ func initAPIClient() {
 			Endpoint:        globalFlags.EcxAPIHost,
 			PlaygroundToken: globalFlags.PlaygroundToken,
 			Debug:           globalFlags.Debug,
+			RateLimit:       globalFlags.RateLimit,
+			RateLimitBurst:  globalFlags.RateLimitBurst,
+		}
+
+		if !globalFlags.NoTokenCache {
+			tokenCache, err := client.NewFileTokenCache("")
+			if err != nil {
+				if globalFlags.Debug {
+					log.Println("Token cache disabled: " + err.Error())
+				}
+			} else {
+				clientParams.TokenCache = tokenCache
+			}
 		}
 
 		EcxAPIClient = client.NewEcxAPIClient(clientParams, globalFlags.EcxAPIHost, globalFlags.NoSSL)
@@ -174,19 +192,14 @@ func initAPIClient() {
 	}
 }
 
-func parseFilteringAttributes(str string) map[string]string {
-	// simple regular expression to match key=value comma separated
-	// for now we only allow one filter
-	// TODO accept more than one filter
-	re := regexp.MustCompile("Key=(.+),Value=(.+)$")
-	data := re.FindAllStringSubmatch(str, -1)
-
-	res := make(map[string]string)
-	for _, kv := range data {
-		k := kv[1]
-		v := kv[2]
-		res[k] = v
-	}
+// legacyFilter the single Key=...,Value=... filter accepted by previous versions
+var legacyFilter = regexp.MustCompile("^Key=(.+),Value=(.+)$")
 
-	return res
+// parseFilter parses the --filter expression, the legacy Key=...,Value=... form is still accepted and matches
+// string fields containing value and any other field equal to value
+func parseFilter(str string) (client.Filter, error) {
+	if kv := legacyFilter.FindStringSubmatch(str); kv != nil {
+		return client.ContainsFilter(kv[1], kv[2]), nil
+	}
+	return client.ParseFilter(str)
 }
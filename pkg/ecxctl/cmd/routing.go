@@ -15,16 +15,18 @@
 package cmd
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"log"
-	"strings"
+	"time"
 
 	"github.com/jxoir/equinix-tools/pkg/ecxlib/api/buyer"
+	client "github.com/jxoir/equinix-tools/pkg/ecxlib/api/client"
 	"github.com/spf13/cobra"
 )
 
-var routingInstanceStates string
+var routingInstanceStates []string
+var routingInstanceFilter string
 var routingInstanceMetro string
 var routingInstanceName string
 
@@ -35,6 +37,16 @@ var routingInstanceAsn int64
 var routingInstanceBgpUseAuth bool
 var routingInstanceBgpAuthorizationKey string
 var routingInstanceNotificationEmails []string
+var routingInstanceWait bool
+var routingInstanceWaitTimeout time.Duration
+
+// flags of routing-instance update and delete
+var updateRoutingInstanceName string
+var updateRoutingInstanceEmails []string
+var updateRoutingInstanceAddEmails []string
+var updateRoutingInstanceRemoveEmails []string
+var updateRoutingInstanceBgpKey string
+var deleteRoutingInstanceYes bool
 
 // metrosCmd represents the metros command
 var routingInstanceCmd = &cobra.Command{
@@ -62,17 +74,40 @@ var routingInstanceCreateCmd = &cobra.Command{
 	Run:   routingInstanceCreateCommand,
 }
 
-var routingInstanceDeleteCmd cobra.Command
-var routingInstanceUpdateCmd cobra.Command
+var routingInstanceGetCmd = &cobra.Command{
+	Use:   "get <uuid>...",
+	Short: "get routing instances by uuid",
+	Args:  cobra.MinimumNArgs(1),
+	Run:   routingInstanceGetCommand,
+}
+
+var routingInstanceUpdateCmd = &cobra.Command{
+	Use:   "update <uuid>",
+	Short: "modify routing instance name, notification emails or rotate its BGP key",
+	Args:  cobra.ExactArgs(1),
+	Run:   routingInstanceUpdateCommand,
+}
+
+var routingInstanceDeleteCmd = &cobra.Command{
+	Use:   "delete <uuid>...",
+	Short: "delete routing instances by uuid",
+	Args:  cobra.MinimumNArgs(1),
+	Run:   routingInstanceDeleteCommand,
+}
 
 func init() {
 	rootCmd.AddCommand(routingInstanceCmd)
 	routingInstanceCmd.AddCommand(routingInstanceListCmd)
 	routingInstanceCmd.AddCommand(routingInstanceCheckNameCmd)
 	routingInstanceCmd.AddCommand(routingInstanceCreateCmd)
+	routingInstanceCmd.AddCommand(routingInstanceGetCmd)
+	routingInstanceCmd.AddCommand(routingInstanceUpdateCmd)
+	routingInstanceCmd.AddCommand(routingInstanceDeleteCmd)
 
 	routingInstanceListCmd.Flags().StringVarP(&routingInstanceMetro, "metro", "", "", "metro code")
-	routingInstanceListCmd.Flags().StringVarP(&routingInstanceStates, "state", "", "PROVISIONED", "routing instances states")
+	routingInstanceListCmd.Flags().StringSliceVarP(&routingInstanceStates, "state", "", []string{"PROVISIONED"}, "routing instances states, repeat or comma separate for several")
+	routingInstanceListCmd.Flags().StringVarP(&routingInstanceFilter, "filter", "f", "", "Filter expression (eg.: 'name~^PROD and asn=65000')")
+	addListFlags(routingInstanceListCmd)
 
 	// Routing instances check name exists command definition and flags
 	routingInstanceCheckNameCmd.Flags().StringVarP(&routingInstanceMetro, "metro", "", "", "metro code")
@@ -84,20 +119,29 @@ func init() {
 	routingInstanceCreateCmd.Flags().StringVarP(&routingInstanceMetro, "metro", "", "", "metro code")
 	routingInstanceCreateCmd.Flags().StringVarP(&routingInstanceName, "name", "", "", "routing instance name (primary)")
 	routingInstanceCreateCmd.Flags().StringVarP(&routingInstanceSecondaryName, "secondary-name", "", "", "routing instance secondary name")
-	routingInstanceCreateCmd.Flags().BoolVarP(&routingInstanceRequiredRedundancy, "redundancy", "", false, "required redundancy")
-	routingInstanceCreateCmd.Flags().Int64VarP(&routingInstanceAsn, "asn-number", "", 0, "asn number")
+	routingInstanceCreateCmd.Flags().BoolVarP(&routingInstanceRequiredRedundancy, "redundancy", "", false, "required redundancy, creates the secondary routing instance too")
+	routingInstanceCreateCmd.Flags().Int64VarP(&routingInstanceAsn, "asn-number", "", 0, "asn number, private (64512-65534, 4200000000-4294967294) for Private route type, public for Public")
 	routingInstanceCreateCmd.Flags().BoolVarP(&routingInstanceBgpUseAuth, "bgp-auth", "", false, "required ASN BGP authentication")
-	routingInstanceCreateCmd.Flags().StringVarP(&routingInstanceBgpAuthorizationKey, "bgp-key", "", "", "bgp auth key if required")
+	routingInstanceCreateCmd.Flags().StringVarP(&routingInstanceBgpAuthorizationKey, "bgp-key", "", "", "bgp auth key if required (6 to 32 characters, no spaces or quotes)")
 	routingInstanceCreateCmd.Flags().StringArrayVarP(&routingInstanceNotificationEmails, "notification-emails", "", []string{}, "notification emails (comma separated)")
 	routingInstanceCreateCmd.Flags().StringVarP(&routingInstanceRouteType, "type", "", "Private", "route type Private or Public")
+	routingInstanceCreateCmd.Flags().BoolVar(&routingInstanceWait, "wait", false, "wait until the routing instances are provisioned")
+	routingInstanceCreateCmd.Flags().DurationVar(&routingInstanceWaitTimeout, "timeout", 15*time.Minute, "maximum time to wait with --wait")
 
 	routingInstanceCreateCmd.MarkFlagRequired("metro")
 	routingInstanceCreateCmd.MarkFlagRequired("name")
-	routingInstanceCreateCmd.MarkFlagRequired("secondary-name")
-	routingInstanceCreateCmd.MarkFlagRequired("asn")
+	routingInstanceCreateCmd.MarkFlagRequired("asn-number")
 	routingInstanceCreateCmd.MarkFlagRequired("notification-emails")
 	routingInstanceCreateCmd.MarkFlagRequired("type")
 
+	routingInstanceUpdateCmd.Flags().StringVarP(&updateRoutingInstanceName, "name", "n", "", "new name for the routing instance")
+	routingInstanceUpdateCmd.Flags().StringSliceVar(&updateRoutingInstanceEmails, "notification-emails", nil, "comma separated emails replacing the notification emails")
+	routingInstanceUpdateCmd.Flags().StringSliceVar(&updateRoutingInstanceAddEmails, "add-notification-email", nil, "email to add to the notification emails")
+	routingInstanceUpdateCmd.Flags().StringSliceVar(&updateRoutingInstanceRemoveEmails, "remove-notification-email", nil, "email to remove from the notification emails")
+	routingInstanceUpdateCmd.Flags().StringVar(&updateRoutingInstanceBgpKey, "bgp-key", "", "new BGP authorization key")
+
+	routingInstanceDeleteCmd.Flags().BoolVarP(&deleteRoutingInstanceYes, "yes", "y", false, "delete without asking for confirmation")
+
 }
 
 func routingInstancesCheckRoutingInstanceNameExistsCommand(cmd *cobra.Command, args []string) {
@@ -113,46 +157,28 @@ func routingInstancesCheckRoutingInstanceNameExistsCommand(cmd *cobra.Command, a
 }
 
 func routingInstancesListCommand(cmd *cobra.Command, args []string) {
-	// Call GetRoutingInstance without params
-
-	metro := ""
-	if routingInstanceMetro != "" {
-		metro = routingInstanceMetro
-	}
-	states := strings.Split(routingInstanceStates, ",")
+	metro := routingInstanceMetro
 	params := buyer.GetAllRoutingInstancesParams{
-		PageNumber: 1,
-		PageSize:   10,
-		States:     states,
-		MetroCode:  &metro,
+		States:    routingInstanceStates,
+		MetroCode: &metro,
 	}
 	routingInstanceList, err := RoutingInstanceAPIClient.GetAllRoutingInstances(&params)
 	if err != nil {
 		log.Fatal(err)
-	} else {
-		if routingInstanceList != nil {
+	}
 
-			routingInstances := routingInstanceList.Payload.RoutingInstances
-			routingInstancesRes, err := json.MarshalIndent(routingInstances, "", "    ")
-			if err != nil {
-				log.Fatal("There was an error with json response:", err)
-			} else {
-				fmt.Println(string(routingInstancesRes))
-			}
+	if routingInstanceFilter != "" {
+		filter, err := parseFilter(routingInstanceFilter)
+		if err != nil {
+			log.Fatal(err)
 		}
-
+		client.ResponseFilterExpression(routingInstanceList, filter)
 	}
+
+	printList(routingInstanceList, routingInstanceColumns)
 }
 
 func routingInstanceCreateCommand(cmd *cobra.Command, args []string) {
-
-	// params = CreateRoutingInstanceParams{}
-
-	if routingInstanceBgpUseAuth && routingInstanceBgpAuthorizationKey == "" {
-		fmt.Println("BGP authorization key required")
-		return
-	}
-
 	params := buyer.CreateRoutingInstanceParams{
 		MetroCode:           routingInstanceMetro,
 		PrimaryName:         routingInstanceName,
@@ -165,12 +191,95 @@ func routingInstanceCreateCommand(cmd *cobra.Command, args []string) {
 		NotificationEmails:  routingInstanceNotificationEmails,
 	}
 
-	riUUID, err := RoutingInstanceAPIClient.CreateRoutingInstance(&params)
-
+	res, err := RoutingInstanceAPIClient.CreateRoutingInstances(&params)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	fmt.Println("Routing instance " + routingInstanceName + " created:" + riUUID)
+	uuids := []string{res.PrimaryRIUUID}
+	fmt.Println("Routing instance " + routingInstanceName + " created:" + res.PrimaryRIUUID)
+	if res.SecondaryRIUUID != "" {
+		uuids = append(uuids, res.SecondaryRIUUID)
+		fmt.Println("Routing instance " + routingInstanceSecondaryName + " created:" + res.SecondaryRIUUID)
+	}
 
+	if routingInstanceWait {
+		// --timeout covers the primary and secondary instances together
+		ctx := context.Background()
+		if routingInstanceWaitTimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, routingInstanceWaitTimeout)
+			defer cancel()
+		}
+		for _, uuid := range uuids {
+			fmt.Printf("Waiting for routing instance %s to be %s\n", uuid, buyer.RoutingInstanceStateProvisioned)
+			instance, err := RoutingInstanceAPIClient.WaitForRoutingInstanceStateWithContext(ctx, uuid, []string{buyer.RoutingInstanceStateProvisioned}, routingInstanceWaitTimeout)
+			if err != nil {
+				log.Fatalf("Error waiting for routing instance %s: %s\n", uuid, err)
+			}
+			fmt.Printf("Routing instance %s is %s\n", uuid, instance.State)
+		}
+	}
+}
+
+func routingInstanceGetCommand(cmd *cobra.Command, args []string) {
+	for _, uuid := range args {
+		instance, err := RoutingInstanceAPIClient.GetRoutingInstance(uuid)
+		if err != nil {
+			log.Fatal(err)
+		}
+		printObject(instance, routingInstanceColumns)
+	}
+}
+
+func routingInstanceUpdateCommand(cmd *cobra.Command, args []string) {
+	uuid := args[0]
+
+	params := &buyer.UpdateRoutingInstanceParams{
+		Name:                     updateRoutingInstanceName,
+		AddNotificationEmails:    updateRoutingInstanceAddEmails,
+		RemoveNotificationEmails: updateRoutingInstanceRemoveEmails,
+		BgpAuthorizationKey:      updateRoutingInstanceBgpKey,
+	}
+	if cmd.Flags().Changed("notification-emails") {
+		params.NotificationEmails = updateRoutingInstanceEmails
+		if params.NotificationEmails == nil {
+			params.NotificationEmails = []string{}
+		}
+	}
+
+	if err := RoutingInstanceAPIClient.UpdateRoutingInstance(uuid, params); err != nil {
+		log.Fatalf("Error updating routing instance: %s\n", err)
+	}
+	fmt.Printf("Routing instance %s succesfully updated\n", uuid)
+}
+
+func routingInstanceDeleteCommand(cmd *cobra.Command, args []string) {
+	instances := &itemList{}
+	for _, uuid := range args {
+		instance, err := RoutingInstanceAPIClient.GetRoutingInstance(uuid)
+		if err != nil {
+			log.Fatal(err)
+		}
+		instances.items = append(instances.items, instance)
+	}
+
+	printList(instances, routingInstanceColumns)
+	if !deleteRoutingInstanceYes && !confirm(fmt.Sprintf("Delete %d routing instances?", len(args))) {
+		fmt.Println("Delete cancelled")
+		return
+	}
+
+	failed := 0
+	for _, uuid := range args {
+		if err := RoutingInstanceAPIClient.DeleteRoutingInstance(uuid); err != nil {
+			failed++
+			fmt.Printf("Error deleting routing instance %s: %s\n", uuid, err)
+			continue
+		}
+		fmt.Printf("Routing instance %s succesfully deleted\n", uuid)
+	}
+	if failed > 0 {
+		log.Fatalf("%d of %d routing instances failed to delete\n", failed, len(args))
+	}
 }
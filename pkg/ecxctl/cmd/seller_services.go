@@ -15,7 +15,6 @@
 package cmd
 
 import (
-	"encoding/json"
 	"fmt"
 	"log"
 	"strings"
@@ -68,11 +67,13 @@ func init() {
 	sellerL2Cmd.AddCommand(sellerListCmd)
 	sellerL2Cmd.AddCommand(sellerGetCmd)
 	sellerListCmd.Flags().StringVarP(&sellerProfileMetro, "metros", "", "", "comma separated list of metro codes")
+	addListFlags(sellerListCmd)
 
 	// Group L3 commands
 	sellerCmd.AddCommand(sellerL3Cmd)
 	sellerL3Cmd.AddCommand(sellerServicesListCmd)
 	sellerServicesListCmd.Flags().StringVarP(&sellerProfileMetro, "metros", "", "", "comma separated list of metro codes")
+	addListFlags(sellerServicesListCmd)
 
 }
 
@@ -87,13 +88,7 @@ func sellerListCommand(cmd *cobra.Command, args []string) {
 
 	if sellerList != nil && sellerList.TotalCount > 0 {
 
-		sellers := sellerList.Items
-		sellersRes, err := json.MarshalIndent(sellers, "", "    ")
-		if err != nil {
-			log.Fatal("There was an error with json response:", err)
-		} else {
-			fmt.Println(string(sellersRes))
-		}
+		printList(sellerList, sellerProfileColumns)
 
 	} else if sellerList != nil && sellerList.TotalCount == 0 {
 		fmt.Println("There are no seller profiles for specified metro")
@@ -114,8 +109,7 @@ func sellerGetByUUIDCommand(cmd *cobra.Command, args []string) {
 			log.Fatal(err)
 		}
 
-		sellerRes, _ := json.MarshalIndent(sellerProfile.Payload, "", "    ")
-		fmt.Println(string(sellerRes))
+		printObject(sellerProfile.Payload, sellerProfileColumns)
 	}
 }
 
@@ -129,13 +123,7 @@ func sellerServicesListCommand(cmd *cobra.Command, args []string) {
 
 	if sellerList != nil && sellerList.TotalCount > 0 {
 
-		sellers := sellerList.Items
-		sellersRes, err := json.MarshalIndent(sellers, "", "    ")
-		if err != nil {
-			log.Fatal("There was an error with json response:", err)
-		} else {
-			fmt.Println(string(sellersRes))
-		}
+		printList(sellerList, sellerServiceColumns)
 	} else if sellerList != nil && sellerList.TotalCount == 0 {
 		fmt.Println("There are no seller services profiles for specified metro")
 	}
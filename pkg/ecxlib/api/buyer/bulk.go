@@ -0,0 +1,177 @@
+package buyer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	client "github.com/jxoir/equinix-tools/pkg/ecxlib/api/client"
+	apiconnections "github.com/jxoir/go-ecxfabric/buyer/client/connections"
+	"github.com/jxoir/go-ecxfabric/buyer/models"
+)
+
+// DefaultBulkConcurrency connections created at the same time by CreateL2Connections
+const DefaultBulkConcurrency = 4
+
+// BulkCreateResult outcome of creating the connection of a row
+type BulkCreateResult struct {
+	Row           int
+	Params        *CreateL2ConnectionParams
+	PrimaryUUID   string
+	SecondaryUUID string
+	Err           error
+}
+
+// vlanKey identity of a vlan on a port, c-tags only matter for QinQ ports
+func vlanKey(portUUID string, sTag int64, cTag string) string {
+	return fmt.Sprintf("%s/%d/%s", portUUID, sTag, cTag)
+}
+
+// validateConnectionRow checks the fields required by CreateL2Connection are set
+func validateConnectionRow(row *CreateL2ConnectionParams) error {
+	switch {
+	case row == nil:
+		return errors.New("empty row")
+	case row.PrimaryName == "":
+		return errors.New("primaryName required")
+	case row.PrimaryPortUUID == "":
+		return errors.New("primaryPortUUID required")
+	case row.PrimaryVlanSTag == 0:
+		return errors.New("primaryVlanSTag required")
+	case row.ProfileUUID == "":
+		return errors.New("profileUUID required")
+	case row.Speed == 0 || row.SpeedUnit == "":
+		return errors.New("speed and speedUnit required")
+	}
+
+	if row.SecondaryName != "" || row.SecondaryPortUUID != "" || row.SecondaryVlanSTag != 0 {
+		switch {
+		case row.SecondaryName == "":
+			return errors.New("secondaryName required for redundant connections")
+		case row.SecondaryPortUUID == "":
+			return errors.New("secondaryPortUUID required for redundant connections")
+		case row.SecondaryVlanSTag == 0:
+			return errors.New("secondaryVlanSTag required for redundant connections")
+		case row.SecondaryPortUUID == row.PrimaryPortUUID:
+			return errors.New("secondaryPortUUID must be different from primaryPortUUID")
+		}
+	}
+	return nil
+}
+
+// connectionSide port and vlan of the primary or secondary connection of a row
+type connectionSide struct {
+	name     string
+	portUUID string
+	sTag     int64
+	cTag     string
+}
+
+// rowSides returns the primary and, for redundant rows, the secondary side of row
+func rowSides(row *CreateL2ConnectionParams) []connectionSide {
+	sides := []connectionSide{{row.PrimaryName, row.PrimaryPortUUID, row.PrimaryVlanSTag, row.PrimaryVlanCTag}}
+	if row.SecondaryName != "" {
+		sides = append(sides, connectionSide{row.SecondaryName, row.SecondaryPortUUID, row.SecondaryVlanSTag, row.SecondaryVlanCTag})
+	}
+	return sides
+}
+
+// ValidateConnectionRows validates every row before any connection is created: required fields, names repeated in
+// rows and vlans used by other rows or by existing active connections. Returns an error per row, nil for valid rows.
+func ValidateConnectionRows(rows []*CreateL2ConnectionParams, existing []*models.GetBuyerConResContent) []error {
+	// existing connections only report s-tags, any c-tag on the same s-tag conflicts
+	usedVlans := map[string]string{}
+	for _, conn := range existing {
+		if conn == nil || containsStatus(inactiveStatuses, conn.Status) {
+			continue
+		}
+		usedVlans[vlanKey(conn.PortUUID, conn.VlanSTag, "")] = conn.UUID
+	}
+
+	errs := make([]error, len(rows))
+	names := map[string]int{}
+	vlans := map[string]int{}
+	for i, row := range rows {
+		if err := validateConnectionRow(row); err != nil {
+			errs[i] = err
+			continue
+		}
+
+		for _, side := range rowSides(row) {
+			key := vlanKey(side.portUUID, side.sTag, side.cTag)
+			if other, ok := names[side.name]; ok && errs[i] == nil {
+				errs[i] = fmt.Errorf("name %s already used by row %d", side.name, other+1)
+			}
+			if other, ok := vlans[key]; ok && errs[i] == nil {
+				errs[i] = fmt.Errorf("vlan %d on port %s already used by row %d", side.sTag, side.portUUID, other+1)
+			}
+			if uuid, ok := usedVlans[vlanKey(side.portUUID, side.sTag, "")]; ok && errs[i] == nil {
+				errs[i] = fmt.Errorf("vlan %d on port %s already used by connection %s", side.sTag, side.portUUID, uuid)
+			}
+			names[side.name] = i
+			vlans[key] = i
+		}
+	}
+	return errs
+}
+
+// CreateL2Connections calls CreateL2ConnectionsWithContext with a background context
+func (m *ECXConnectionsAPI) CreateL2Connections(rows []*CreateL2ConnectionParams, concurrency int, ecxseller *ECXSellerServicesAPI) []*BulkCreateResult {
+	return m.CreateL2ConnectionsWithContext(context.Background(), rows, concurrency, ecxseller)
+}
+
+// CreateL2ConnectionsWithContext creates the connection of every row, up to concurrency at the same time, and returns
+// the results in row order. With ecxseller the connections are created with CreateL2ConnectionToSellerProfile,
+// validating the seller profile first. Rows should be validated with ValidateConnectionRows.
+func (m *ECXConnectionsAPI) CreateL2ConnectionsWithContext(ctx context.Context, rows []*CreateL2ConnectionParams, concurrency int, ecxseller *ECXSellerServicesAPI) []*BulkCreateResult {
+	results := make([]*BulkCreateResult, len(rows))
+	for i, row := range rows {
+		results[i] = &BulkCreateResult{Row: i, Params: row}
+	}
+
+	errs := client.Parallel(ctx, concurrency, len(rows), func(ctx context.Context, i int) error {
+		var conn *apiconnections.CreateConnectionUsingPOSTOK
+		var err error
+		if ecxseller != nil {
+			conn, err = m.CreateL2ConnectionToSellerProfileWithContext(ctx, rows[i], ecxseller)
+		} else {
+			conn, err = m.CreateL2ConnectionWithContext(ctx, rows[i])
+		}
+		if err == nil {
+			results[i].PrimaryUUID = conn.Payload.PrimaryConnectionID
+			results[i].SecondaryUUID = conn.Payload.SecondaryConnectionID
+		}
+		return err
+	})
+	for i, err := range errs {
+		results[i].Err = err
+	}
+
+	return results
+}
+
+// BulkDeleteResult outcome of deleting a connection
+type BulkDeleteResult struct {
+	UUID string
+	Err  error
+}
+
+// DeleteByUUIDs calls DeleteByUUIDsWithContext with a background context
+func (m *ECXConnectionsAPI) DeleteByUUIDs(uuids []string, concurrency int) []*BulkDeleteResult {
+	return m.DeleteByUUIDsWithContext(context.Background(), uuids, concurrency)
+}
+
+// DeleteByUUIDsWithContext deletes every connection of uuids, up to concurrency at the same time, and returns the
+// results in uuids order, a failed delete doesn't stop the others
+func (m *ECXConnectionsAPI) DeleteByUUIDsWithContext(ctx context.Context, uuids []string, concurrency int) []*BulkDeleteResult {
+	errs := client.Parallel(ctx, concurrency, len(uuids), func(ctx context.Context, i int) error {
+		_, err := m.DeleteByUUIDWithContext(ctx, uuids[i])
+		return err
+	})
+
+	results := make([]*BulkDeleteResult, len(uuids))
+	for i, uuid := range uuids {
+		results[i] = &BulkDeleteResult{UUID: uuid, Err: errs[i]}
+	}
+	return results
+}
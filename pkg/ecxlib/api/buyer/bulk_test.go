@@ -0,0 +1,134 @@
+package buyer
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jxoir/go-ecxfabric/buyer/models"
+)
+
+func TestValidateConnectionRows(t *testing.T) {
+	row := func(name string, port string, vlan int64) *CreateL2ConnectionParams {
+		return &CreateL2ConnectionParams{PrimaryName: name, PrimaryPortUUID: port, PrimaryVlanSTag: vlan, ProfileUUID: "seller-1", Speed: 50, SpeedUnit: "MB"}
+	}
+	redundant := row("E", "port-1", 500)
+	redundant.SecondaryName, redundant.SecondaryPortUUID, redundant.SecondaryVlanSTag = "E-SEC", "port-2", 100
+
+	rows := []*CreateL2ConnectionParams{
+		row("A", "port-1", 100),
+		row("B", "port-1", 100),
+		row("A", "port-1", 200),
+		row("C", "port-1", 300),
+		row("D", "", 400),
+		redundant,
+		row("F", "port-2", 100),
+	}
+	existing := []*models.GetBuyerConResContent{
+		{UUID: "uuid-1", PortUUID: "port-1", VlanSTag: 300, Status: "PROVISIONED"},
+		{UUID: "uuid-2", PortUUID: "port-1", VlanSTag: 600, Status: ConnectionStatusDeprovisioned},
+	}
+
+	errs := ValidateConnectionRows(rows, existing)
+	valid := []bool{true, false, false, false, false, true, false}
+	for i, err := range errs {
+		if (err == nil) != valid[i] {
+			t.Errorf("Row %d: expected valid %t, received %v", i+1, valid[i], err)
+		}
+	}
+}
+
+func TestCreateL2Connections(t *testing.T) {
+	var running, maxRunning, created int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ecx/v3/l2/connections", func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			max := atomic.LoadInt32(&maxRunning)
+			if n <= max || atomic.CompareAndSwapInt32(&maxRunning, max, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+
+		request := &models.PostConnectionRequest{}
+		json.NewDecoder(r.Body).Decode(request)
+		w.Header().Set("Content-Type", "application/json")
+		if request.PrimaryName == "FAIL" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `[{"errorCode":"IC-LAYER2-4001","errorMessage":"Invalid vlan"}]`)
+			return
+		}
+		fmt.Fprintf(w, `{"primaryConnectionId":"uuid-%s","status":"SUCCESS"}`, request.PrimaryName)
+		atomic.AddInt32(&created, 1)
+	})
+	ec, server := newTestClient(mux)
+	defer server.Close()
+	api := NewECXConnectionsAPI(ec)
+
+	var rows []*CreateL2ConnectionParams
+	for i := 0; i < 8; i++ {
+		rows = append(rows, &CreateL2ConnectionParams{PrimaryName: fmt.Sprint(i), PrimaryPortUUID: "port-1", PrimaryVlanSTag: int64(100 + i), ProfileUUID: "seller-1"})
+	}
+	rows[3].PrimaryName = "FAIL"
+
+	results := api.CreateL2Connections(rows, 2, nil)
+	for i, result := range results {
+		if result.Row != i {
+			t.Errorf("Expected result %d in row order, received row %d", i, result.Row)
+		}
+		if i == 3 {
+			if result.Err == nil {
+				t.Errorf("Expected error on row 4")
+			}
+			continue
+		}
+		if result.Err != nil || result.PrimaryUUID != "uuid-"+rows[i].PrimaryName {
+			t.Errorf("Row %d: unexpected result %+v", i+1, result)
+		}
+	}
+	if created != 7 {
+		t.Errorf("Expected 7 connections created, received %d", created)
+	}
+	if maxRunning > 2 {
+		t.Errorf("Expected at most 2 concurrent requests, received %d", maxRunning)
+	}
+}
+
+func TestDeleteByUUIDs(t *testing.T) {
+	var deleted int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ecx/v3/l2/connections/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		uuid := strings.TrimPrefix(r.URL.Path, "/ecx/v3/l2/connections/")
+		if r.Method != http.MethodDelete || uuid == "missing" {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"errorCode":"IC-LAYER2-4004","errorMessage":"Connection not found"}`)
+			return
+		}
+		atomic.AddInt32(&deleted, 1)
+		fmt.Fprintf(w, `{"message":"Connection deleted","primaryConnectionId":%q}`, uuid)
+	})
+	ec, server := newTestClient(mux)
+	defer server.Close()
+	api := NewECXConnectionsAPI(ec)
+
+	uuids := []string{"uuid-1", "missing", "uuid-3"}
+	results := api.DeleteByUUIDs(uuids, 2)
+	for i, result := range results {
+		if result.UUID != uuids[i] {
+			t.Errorf("Expected result %d for %s, received %s", i, uuids[i], result.UUID)
+		}
+		if (result.Err != nil) != (uuids[i] == "missing") {
+			t.Errorf("Unexpected result for %s: %v", result.UUID, result.Err)
+		}
+	}
+	if deleted != 2 {
+		t.Errorf("Expected 2 connections deleted, received %d", deleted)
+	}
+}
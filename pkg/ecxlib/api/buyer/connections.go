@@ -1,11 +1,16 @@
 package buyer
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log"
-	"math"
+	"net/http"
+	"strings"
+	"time"
 
+	"github.com/go-openapi/runtime"
+	"github.com/go-openapi/strfmt"
 	client "github.com/jxoir/equinix-tools/pkg/ecxlib/api/client"
 	apiconnections "github.com/jxoir/go-ecxfabric/buyer/client/connections"
 	"github.com/jxoir/go-ecxfabric/buyer/models"
@@ -16,117 +21,164 @@ type ECXConnectionsAPI struct {
 	*client.EquinixAPIClient
 }
 
-// ConnectionsResponse initial wrapper for swagger GetBuyerConResContent
+// Connection statuses
+const (
+	ConnectionStatusProvisioned   = "PROVISIONED"
+	ConnectionStatusDeprovisioned = "DEPROVISIONED"
+	// ConnectionStatusNotFound isn't returned by ECX, waiting for it succeeds once the connection can't be found
+	ConnectionStatusNotFound = "NOT_FOUND"
+)
+
+// ConnectionFailedStatuses statuses stopping WaitForConnectionState with a ConnectionStatusError, unless waited for
+var ConnectionFailedStatuses = []string{"FAILED", "REJECTED", "NOT_PROVISIONED", ConnectionStatusDeprovisioned}
+
+// ErrWaitTimeout returned by WaitForConnectionState and WaitForRoutingInstanceState when the timeout expires
+var ErrWaitTimeout = errors.New("timed out waiting for status")
+
+// ConnectionStatusError connection reached a failed status while waiting for another one
+type ConnectionStatusError struct {
+	UUID   string
+	Status string
+}
+
+// Error returns the connection and its status
+func (e *ConnectionStatusError) Error() string {
+	return fmt.Sprintf("connection %s reached status %s", e.UUID, e.Status)
+}
+
+// ErrSpeedNotAllowed returned by UpdateConnection when the seller profile doesn't offer the requested speed
+var ErrSpeedNotAllowed = errors.New("speed not allowed by seller profile")
+
+// ErrInvalidAuthorizationKey returned by ValidateAuthorizationKey when ECX rejects the key for the seller profile
+var ErrInvalidAuthorizationKey = errors.New("invalid authorization key")
+
+// ErrAuthorizationKeyValidationUnavailable returned by ValidateAuthorizationKey when ECX can't validate keys for the
+// seller profile, the key may still be valid
+var ErrAuthorizationKeyValidationUnavailable = errors.New("authorization key validation unavailable")
+
+// AuthorizationKeyValid status of a valid authorization key
+const AuthorizationKeyValid = "VALID"
+
+// ErrNothingToUpdate returned by UpdateConnection when the params don't change the connection
+var ErrNothingToUpdate = errors.New("nothing to update")
+
+// ConnectionsResponse wrapper for swagger GetBuyerConResContent list
 type ConnectionsResponse struct {
-	Items          []interface{}
+	client.Collection[*models.GetBuyerConResContent]
 	PageTotalCount int64
 	PageSize       int64
 }
 
 type CreateL2ConnectionParams struct {
 	// authorization key
-	AuthorizationKey string `json:"authorizationKey,omitempty"`
+	AuthorizationKey string `json:"authorizationKey,omitempty" yaml:"authorizationKey,omitempty"`
 
 	// named tag
-	NamedTag string `json:"namedTag,omitempty"`
+	NamedTag string `json:"namedTag,omitempty" yaml:"namedTag,omitempty"`
 
 	// notifications
-	Notifications []string `json:"notifications"`
+	Notifications []string `json:"notifications" yaml:"notifications,omitempty"`
 
 	// primary name
-	PrimaryName string `json:"primaryName,omitempty"`
+	PrimaryName string `json:"primaryName,omitempty" yaml:"primaryName,omitempty"`
 
 	// primary port UUID
-	PrimaryPortUUID string `json:"primaryPortUUID,omitempty"`
+	PrimaryPortUUID string `json:"primaryPortUUID,omitempty" yaml:"primaryPortUUID,omitempty"`
 
 	// primary vlan c tag
-	PrimaryVlanCTag string `json:"primaryVlanCTag,omitempty"`
+	PrimaryVlanCTag string `json:"primaryVlanCTag,omitempty" yaml:"primaryVlanCTag,omitempty"`
 
 	// primary vlan s tag
-	PrimaryVlanSTag int64 `json:"primaryVlanSTag,omitempty"`
+	PrimaryVlanSTag int64 `json:"primaryVlanSTag,omitempty" yaml:"primaryVlanSTag,omitempty"`
 
 	// primary z side port UUID
-	PrimaryZSidePortUUID string `json:"primaryZSidePortUUID,omitempty"`
+	PrimaryZSidePortUUID string `json:"primaryZSidePortUUID,omitempty" yaml:"primaryZSidePortUUID,omitempty"`
 
 	// primary z side vlan c tag
-	PrimaryZSideVlanCTag int64 `json:"primaryZSideVlanCTag,omitempty"`
+	PrimaryZSideVlanCTag int64 `json:"primaryZSideVlanCTag,omitempty" yaml:"primaryZSideVlanCTag,omitempty"`
 
 	// primary z side vlan s tag
-	PrimaryZSideVlanSTag int64 `json:"primaryZSideVlanSTag,omitempty"`
+	PrimaryZSideVlanSTag int64 `json:"primaryZSideVlanSTag,omitempty" yaml:"primaryZSideVlanSTag,omitempty"`
 
 	// profile UUID
-	ProfileUUID string `json:"profileUUID,omitempty"`
+	ProfileUUID string `json:"profileUUID,omitempty" yaml:"profileUUID,omitempty"`
 
 	// purchase order number
-	PurchaseOrderNumber string `json:"purchaseOrderNumber,omitempty"`
+	PurchaseOrderNumber string `json:"purchaseOrderNumber,omitempty" yaml:"purchaseOrderNumber,omitempty"`
 
 	// secondary name
-	SecondaryName string `json:"secondaryName,omitempty"`
+	SecondaryName string `json:"secondaryName,omitempty" yaml:"secondaryName,omitempty"`
 
 	// secondary port UUID
-	SecondaryPortUUID string `json:"secondaryPortUUID,omitempty"`
+	SecondaryPortUUID string `json:"secondaryPortUUID,omitempty" yaml:"secondaryPortUUID,omitempty"`
 
 	// secondary vlan c tag
-	SecondaryVlanCTag string `json:"secondaryVlanCTag,omitempty"`
+	SecondaryVlanCTag string `json:"secondaryVlanCTag,omitempty" yaml:"secondaryVlanCTag,omitempty"`
 
 	// secondary vlan s tag
-	SecondaryVlanSTag int64 `json:"secondaryVlanSTag,omitempty"`
+	SecondaryVlanSTag int64 `json:"secondaryVlanSTag,omitempty" yaml:"secondaryVlanSTag,omitempty"`
 
 	// secondary z side port UUID
-	SecondaryZSidePortUUID string `json:"secondaryZSidePortUUID,omitempty"`
+	SecondaryZSidePortUUID string `json:"secondaryZSidePortUUID,omitempty" yaml:"secondaryZSidePortUUID,omitempty"`
 
 	// secondary z side vlan c tag
-	SecondaryZSideVlanCTag int64 `json:"secondaryZSideVlanCTag,omitempty"`
+	SecondaryZSideVlanCTag int64 `json:"secondaryZSideVlanCTag,omitempty" yaml:"secondaryZSideVlanCTag,omitempty"`
 
 	// secondary z side vlan s tag
-	SecondaryZSideVlanSTag int64 `json:"secondaryZSideVlanSTag,omitempty"`
+	SecondaryZSideVlanSTag int64 `json:"secondaryZSideVlanSTag,omitempty" yaml:"secondaryZSideVlanSTag,omitempty"`
 
 	// seller metro code
-	SellerMetroCode string `json:"sellerMetroCode,omitempty"`
+	SellerMetroCode string `json:"sellerMetroCode,omitempty" yaml:"sellerMetroCode,omitempty"`
 
 	// seller region
-	SellerRegion string `json:"sellerRegion,omitempty"`
+	SellerRegion string `json:"sellerRegion,omitempty" yaml:"sellerRegion,omitempty"`
 
 	// speed
-	Speed int64 `json:"speed,omitempty"`
+	Speed int64 `json:"speed,omitempty" yaml:"speed,omitempty"`
 
 	// speed unit
-	SpeedUnit string `json:"speedUnit,omitempty"`
+	SpeedUnit string `json:"speedUnit,omitempty" yaml:"speedUnit,omitempty"`
 }
 
-// AppendItems appends slice of interface items to internal Items
-func (c *ConnectionsResponse) AppendItems(items []interface{}) {
-	c.Items = append(c.Items, items...)
-}
+// UpdateConnectionParams changes applied by UpdateConnection, empty fields keep the current value
+type UpdateConnectionParams struct {
+	Name string
 
-// SetItems replaces internal slice of interface items with items
-func (c *ConnectionsResponse) SetItems(items []interface{}) {
-	c.Items = items
-}
+	// Speed new speed, SpeedUnit defaults to the current unit of the connection
+	Speed     int64
+	SpeedUnit string
 
-// GetItems retrieves all Items
-func (c *ConnectionsResponse) GetItems() []interface{} {
-	return c.Items
+	// Notifications replaces the notification emails, AddNotifications and RemoveNotifications edit the current ones
+	Notifications       []string
+	AddNotifications    []string
+	RemoveNotifications []string
 }
 
-// FilterItems applies specific filters to items and updates internal items
-func (c *ConnectionsResponse) FilterItems(filters map[string]string) {
-	client.ResponseFilter(c, filters)
+// updateConnectionRequest body of the ECX v3 "update" action of PATCH /ecx/v3/l2/connections/{connId} (Equinix
+// developer portal, ECX Fabric API reference, Connections > Update connection). The vendored swagger predates the
+// action, its PatchRequest body only covers the seller accept/reject actions of the same path.
+type updateConnectionRequest struct {
+	Name          string   `json:"name,omitempty"`
+	Speed         int64    `json:"speed,omitempty"`
+	SpeedUnit     string   `json:"speedUnit,omitempty"`
+	Notifications []string `json:"notifications,omitempty"`
 }
 
-// Count return total count of items
-func (c *ConnectionsResponse) Count() int {
-	return len(c.Items)
+// updateConnectionParams writes updateConnectionRequest into a swagger request
+type updateConnectionParams struct {
+	ConnID  string
+	Request *updateConnectionRequest
 }
 
-// parseContent copy from the returned slice of pointers GetBuyerConResContent to a []interface{}
-func (c *ConnectionsResponse) parseContent(payload []*models.GetBuyerConResContent) []interface{} {
-	interfaceSlice := make([]interface{}, len(payload))
-	for i, d := range payload {
-		//interfaceSlice[i] = d
-		interfaceSlice[i] = d
+// WriteToRequest writes these params to a swagger request
+func (o *updateConnectionParams) WriteToRequest(r runtime.ClientRequest, reg strfmt.Registry) error {
+	if err := r.SetQueryParam("action", "update"); err != nil {
+		return err
+	}
+	if err := r.SetPathParam("connId", o.ConnID); err != nil {
+		return err
 	}
-	return interfaceSlice
+	return r.SetBodyParam(o.Request)
 }
 
 // NewECXConnectionsAPI returns instantiated ECXConnectionsAPI struct
@@ -139,45 +191,72 @@ func (m *ECXConnectionsAPI) NewCreateL2ConnectionParams() *CreateL2ConnectionPar
 	return &CreateL2ConnectionParams{}
 }
 
-// GetAllBuyerConnections get all buyer connections (traversing pagination)
+// GetAllBuyerConnections calls GetAllBuyerConnectionsWithContext with a background context
 func (m *ECXConnectionsAPI) GetAllBuyerConnections(metro *string) (*ConnectionsResponse, error) {
+	return m.GetAllBuyerConnectionsWithContext(context.Background(), metro)
+}
+
+// GetAllBuyerConnectionsWithContext get all buyer connections (traversing pagination), on error returns the
+// connections fetched so far along with the error
+func (m *ECXConnectionsAPI) GetAllBuyerConnectionsWithContext(ctx context.Context, metro *string) (*ConnectionsResponse, error) {
 
-	connectionsList, err := m.GetBuyerConnections(nil, nil, metro)
+	connectionsList, err := m.GetBuyerConnectionsWithContext(ctx, nil, nil, metro)
 	if err != nil {
 		return nil, err
 	}
 
-	totalCount := connectionsList.PageTotalCount
-	pageSize := connectionsList.PageSize
-	totalPages := int64(math.Ceil(float64(totalCount) / float64(pageSize)))
-
-	if pageSize > 0 && totalCount > 0 {
-		// Start iterating from page 1 as we have "page 0" (yeah...swagger implementation of first page)
-		psize := int32(pageSize)
-		for p := 1; p <= int(totalPages-1); p++ {
-			next := int32(p)
-			req, err := m.GetBuyerConnections(&next, &psize, metro)
-			if err != nil {
-				return nil, err
-			} else {
-				//connectionsList.Items = append(append(connectionsList.Items, req.Items...))
-				connectionsList.AppendItems(req.Items)
-			}
+	err = m.WalkPages(ctx, connectionsList.PageTotalCount, connectionsList.PageSize,
+		func(ctx context.Context, pageNumber int32, pageSize int32) (interface{}, error) {
+			return m.GetBuyerConnectionsWithContext(ctx, &pageNumber, &pageSize, metro)
+		},
+		func(page interface{}) {
+			connectionsList.Items = append(connectionsList.Items, page.(*ConnectionsResponse).Items...)
+		})
 
+	return connectionsList, err
+
+}
+
+// ConnectionsIterator iterates over buyer connections fetching pages lazily
+type ConnectionsIterator struct {
+	*client.PageIterator
+}
+
+// Item returns the current connection
+func (it *ConnectionsIterator) Item() *models.GetBuyerConResContent {
+	item, _ := it.PageIterator.Item().(*models.GetBuyerConResContent)
+	return item
+}
+
+// IterateBuyerConnections returns an iterator over all buyer connections, pages are fetched as the iterator advances
+func (m *ECXConnectionsAPI) IterateBuyerConnections(ctx context.Context, metro *string) *ConnectionsIterator {
+	fetch := func(ctx context.Context, pageNumber *int32, pageSize *int32) (*client.IteratorPage, error) {
+		connectionsList, err := m.GetBuyerConnectionsWithContext(ctx, pageNumber, pageSize, metro)
+		if err != nil {
+			return nil, err
 		}
+		return &client.IteratorPage{
+			Items:      connectionsList.GetItems(),
+			TotalCount: connectionsList.PageTotalCount,
+			PageSize:   connectionsList.PageSize,
+		}, nil
 	}
-	return connectionsList, nil
-
+	return &ConnectionsIterator{client.NewPageIterator(ctx, fetch)}
 }
 
-// GetBuyerConnections retrieve list of buyer connections for a specific page number and specific page size
+// GetBuyerConnections calls GetBuyerConnectionsWithContext with a background context
 func (m *ECXConnectionsAPI) GetBuyerConnections(pageNumber *int32, pageSize *int32, metro *string) (*ConnectionsResponse, error) {
-	token, err := m.GetToken()
+	return m.GetBuyerConnectionsWithContext(context.Background(), pageNumber, pageSize, metro)
+}
+
+// GetBuyerConnectionsWithContext retrieve list of buyer connections for a specific page number and specific page size
+func (m *ECXConnectionsAPI) GetBuyerConnectionsWithContext(ctx context.Context, pageNumber *int32, pageSize *int32, metro *string) (*ConnectionsResponse, error) {
+	token, err := m.GetTokenWithContext(ctx)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
-	params := apiconnections.NewGetAllBuyerConnectionsUsingGETParams()
+	params := apiconnections.NewGetAllBuyerConnectionsUsingGETParamsWithContext(ctx)
 
 	if metro != nil && *metro != "" {
 		params.MetroCode = metro
@@ -202,47 +281,35 @@ func (m *ECXConnectionsAPI) GetBuyerConnections(pageNumber *int32, pageSize *int
 
 	}
 	if err != nil {
-
-		switch t := err.(type) {
-		default:
-			return nil, err
-		case *apiconnections.GetAllBuyerConnectionsUsingGETBadRequest:
-			// specific get bad request for envelope errors...
-			return nil, t
-		}
+		return nil, err
 	}
 
 	connectionsList.PageSize = connectionsOK.Payload.PageSize
 	connectionsList.PageTotalCount = connectionsOK.Payload.TotalCount
-	connectionsList.SetItems(connectionsList.parseContent(connectionsOK.Payload.Content))
+	connectionsList.Items = connectionsOK.Payload.Content
 
 	return &connectionsList, nil
 
 }
 
-// GetByUUID get connection by uuid
+// GetByUUID calls GetByUUIDWithContext with a background context
 func (m *ECXConnectionsAPI) GetByUUID(uuid string) (*apiconnections.GetConnectionByUUIDUsingGETOK, error) {
-	params := apiconnections.NewGetConnectionByUUIDUsingGETParams()
+	return m.GetByUUIDWithContext(context.Background(), uuid)
+}
+
+// GetByUUIDWithContext get connection by uuid
+func (m *ECXConnectionsAPI) GetByUUIDWithContext(ctx context.Context, uuid string) (*apiconnections.GetConnectionByUUIDUsingGETOK, error) {
+	params := apiconnections.NewGetConnectionByUUIDUsingGETParamsWithContext(ctx)
 	params.ConnID = uuid
 
-	token, err := m.GetToken()
+	token, err := m.GetTokenWithContext(ctx)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
 	connectionOK, _, err := m.Buyer.Connections.GetConnectionByUUIDUsingGET(params, token)
 	if err != nil {
-		switch t := err.(type) {
-		default:
-			return nil, err
-		case *apiconnections.GetConnectionByUUIDUsingGETBadRequest:
-			// check for envelope message in badrequest - deprecated
-			/** for _, getconnerrors := range t.Payload {
-				fmt.Println(getconnerrors.ErrorMessage + ":" + uuid)
-				return nil, err
-			}**/
-			return nil, t
-		}
+		return nil, err
 	}
 
 	if connectionOK != nil {
@@ -253,37 +320,62 @@ func (m *ECXConnectionsAPI) GetByUUID(uuid string) (*apiconnections.GetConnectio
 
 }
 
-// DeleteByUUID delete connection by uuid
+// PortConnections returns the conns riding portUUID, on their a-side or z-side
+func PortConnections(portUUID string, conns []*models.GetBuyerConResContent) *ConnectionsResponse {
+	portConns := &ConnectionsResponse{}
+	for _, conn := range conns {
+		if conn != nil && (conn.PortUUID == portUUID || conn.ZSidePortUUID == portUUID) {
+			portConns.Items = append(portConns.Items, conn)
+		}
+	}
+	return portConns
+}
+
+// GetPortConnections calls GetPortConnectionsWithContext with a background context
+func (m *ECXConnectionsAPI) GetPortConnections(portUUID string) (*ConnectionsResponse, error) {
+	return m.GetPortConnectionsWithContext(context.Background(), portUUID)
+}
+
+// GetPortConnectionsWithContext returns the buyer connections riding portUUID
+func (m *ECXConnectionsAPI) GetPortConnectionsWithContext(ctx context.Context, portUUID string) (*ConnectionsResponse, error) {
+	connList, err := m.GetAllBuyerConnectionsWithContext(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return PortConnections(portUUID, connList.Items), nil
+}
+
+// DeleteByUUID calls DeleteByUUIDWithContext with a background context
 func (m *ECXConnectionsAPI) DeleteByUUID(uuid string) (*apiconnections.DeleteConnectionUsingDELETEOK, error) {
-	params := apiconnections.NewDeleteConnectionUsingDELETEParams()
+	return m.DeleteByUUIDWithContext(context.Background(), uuid)
+}
+
+// DeleteByUUIDWithContext delete connection by uuid
+func (m *ECXConnectionsAPI) DeleteByUUIDWithContext(ctx context.Context, uuid string) (*apiconnections.DeleteConnectionUsingDELETEOK, error) {
+	params := apiconnections.NewDeleteConnectionUsingDELETEParamsWithContext(ctx)
 	params.SetConnID(uuid)
 
-	token, err := m.GetToken()
+	token, err := m.GetTokenWithContext(ctx)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
 	deleteOK, err := m.Buyer.Connections.DeleteConnectionUsingDELETE(params, token)
 	if err != nil {
-		switch t := err.(type) {
-		default:
-			return nil, err
-		case *apiconnections.GetConnectionByUUIDUsingGETBadRequest:
-			// check for envelope message in badrequest - deprecated
-			/** for _, getconnerrors := range t.Payload {
-				fmt.Println(getconnerrors.ErrorMessage + ":" + uuid)
-				return nil, err
-			}**/
-			return nil, t
-		}
+		return nil, err
 	}
 
 	return deleteOK, nil
 
 }
 
-// CreateL2ConnectionToSellerProfile creates an L2 connection to a specific service seller profile, requires ECXSellerServicesAPI
+// CreateL2ConnectionToSellerProfile calls CreateL2ConnectionToSellerProfileWithContext with a background context
 func (m *ECXConnectionsAPI) CreateL2ConnectionToSellerProfile(params *CreateL2ConnectionParams, ecxseller *ECXSellerServicesAPI) (*apiconnections.CreateConnectionUsingPOSTOK, error) {
+	return m.CreateL2ConnectionToSellerProfileWithContext(context.Background(), params, ecxseller)
+}
+
+// CreateL2ConnectionToSellerProfileWithContext creates an L2 connection to a specific service seller profile, requires ECXSellerServicesAPI
+func (m *ECXConnectionsAPI) CreateL2ConnectionToSellerProfileWithContext(ctx context.Context, params *CreateL2ConnectionParams, ecxseller *ECXSellerServicesAPI) (*apiconnections.CreateConnectionUsingPOSTOK, error) {
 	if params == nil {
 		return nil, errors.New("Parameters to create L2 connection not provided")
 	}
@@ -301,17 +393,19 @@ func (m *ECXConnectionsAPI) CreateL2ConnectionToSellerProfile(params *CreateL2Co
 	}
 
 	// first we obtain the seller profile
-	seller, err := ecxseller.GetSellerProfileByUUID(params.ProfileUUID)
+	seller, err := ecxseller.GetSellerProfileByUUIDWithContext(ctx, params.ProfileUUID)
 	if err != nil {
-		s := fmt.Sprintf("can't obtain seller profile for %s UUID", params.ProfileUUID)
-		return nil, errors.New(s)
+		return nil, fmt.Errorf("can't obtain seller profile for %s UUID: %w", params.ProfileUUID, err)
+	}
+	if seller == nil {
+		return nil, fmt.Errorf("can't obtain seller profile for %s UUID", params.ProfileUUID)
 	}
 
 	if m.Debug {
 		log.Printf("Trying to validate integration ID %s\n", seller.Payload.IntegrationID)
 	}
 	// validate the integrationId
-	integrationIDOk, err := ecxseller.ValidateIntegrationID(seller.Payload.IntegrationID)
+	integrationIDOk, err := ecxseller.ValidateIntegrationIDWithContext(ctx, seller.Payload.IntegrationID)
 	if err != nil {
 		return nil, err
 	}
@@ -344,7 +438,7 @@ func (m *ECXConnectionsAPI) CreateL2ConnectionToSellerProfile(params *CreateL2Co
 
 	// seller.Payload.IntegrationID
 
-	ecxAPIParams := apiconnections.NewCreateConnectionUsingPOSTParams()
+	ecxAPIParams := apiconnections.NewCreateConnectionUsingPOSTParamsWithContext(ctx)
 	request := &models.PostConnectionRequest{
 		PrimaryName:       params.PrimaryName,
 		PrimaryPortUUID:   params.PrimaryPortUUID,
@@ -364,21 +458,13 @@ func (m *ECXConnectionsAPI) CreateL2ConnectionToSellerProfile(params *CreateL2Co
 
 	ecxAPIParams.Request = request
 
-	token, err := m.GetToken()
+	token, err := m.GetTokenWithContext(ctx)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
 	connOk, err := m.Buyer.Connections.CreateConnectionUsingPOST(ecxAPIParams, token)
 	if err != nil {
-		// TODO create an APIError response interface and struct with a getmessages method
-		/**
-		switch t := err.(type) {
-		case *apiconnections.CreateConnectionUsingPOSTBadRequest:
-			return nil, errors.New(t.Payload)
-		default:
-			return nil, err
-		}**/
 		return nil, err
 	}
 
@@ -386,8 +472,13 @@ func (m *ECXConnectionsAPI) CreateL2ConnectionToSellerProfile(params *CreateL2Co
 
 }
 
-// CreateL2Connection creates an L2 connection to a specific service profile
+// CreateL2Connection calls CreateL2ConnectionWithContext with a background context
 func (m *ECXConnectionsAPI) CreateL2Connection(params *CreateL2ConnectionParams) (*apiconnections.CreateConnectionUsingPOSTOK, error) {
+	return m.CreateL2ConnectionWithContext(context.Background(), params)
+}
+
+// CreateL2ConnectionWithContext creates an L2 connection to a specific service profile
+func (m *ECXConnectionsAPI) CreateL2ConnectionWithContext(ctx context.Context, params *CreateL2ConnectionParams) (*apiconnections.CreateConnectionUsingPOSTOK, error) {
 	if params == nil {
 		return nil, errors.New("Parameters to create L2 connection not provided")
 	}
@@ -405,7 +496,7 @@ func (m *ECXConnectionsAPI) CreateL2Connection(params *CreateL2ConnectionParams)
 	}
 	// seller.Payload.IntegrationID
 
-	ecxAPIParams := apiconnections.NewCreateConnectionUsingPOSTParams()
+	ecxAPIParams := apiconnections.NewCreateConnectionUsingPOSTParamsWithContext(ctx)
 	request := &models.PostConnectionRequest{
 		PrimaryName:       params.PrimaryName,
 		PrimaryPortUUID:   params.PrimaryPortUUID,
@@ -425,24 +516,278 @@ func (m *ECXConnectionsAPI) CreateL2Connection(params *CreateL2ConnectionParams)
 
 	ecxAPIParams.Request = request
 
-	token, err := m.GetToken()
+	token, err := m.GetTokenWithContext(ctx)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
 	connOk, err := m.Buyer.Connections.CreateConnectionUsingPOST(ecxAPIParams, token)
 	if err != nil {
-		// TODO create an APIError response interface and struct with a getmessages method
-		/**
-		switch t := err.(type) {
-		case *apiconnections.CreateConnectionUsingPOSTBadRequest:
-			return nil, errors.New(t.Payload)
-		default:
-			return nil, err
-		}**/
 		return nil, err
 	}
 
 	return connOk, nil
 
 }
+
+// ValidateAuthorizationKey calls ValidateAuthorizationKeyWithContext with a background context
+func (m *ECXConnectionsAPI) ValidateAuthorizationKey(profileUUID string, authorizationKey string, metro string, region string) (*models.GetValidateAuthKeyRes, error) {
+	return m.ValidateAuthorizationKeyWithContext(context.Background(), profileUUID, authorizationKey, metro, region)
+}
+
+// ValidateAuthorizationKeyWithContext asks ECX to validate the authorization key against the seller profile for the
+// seller metro (and region, for sellers requiring it). Returns the validation along with an error wrapping
+// ErrInvalidAuthorizationKey if the key isn't valid, or ErrAuthorizationKeyValidationUnavailable if the seller
+// profile doesn't support the validation
+func (m *ECXConnectionsAPI) ValidateAuthorizationKeyWithContext(ctx context.Context, profileUUID string, authorizationKey string, metro string, region string) (*models.GetValidateAuthKeyRes, error) {
+	if profileUUID == "" {
+		return nil, errors.New("must provide seller profile UUID")
+	}
+	if authorizationKey == "" {
+		return nil, errors.New("must provide the authorization key")
+	}
+	if metro == "" {
+		return nil, errors.New("must provide the seller metro code")
+	}
+
+	token, err := m.GetTokenWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	params := apiconnections.NewValidateAuthorizationKeyUsingGETParamsWithContext(ctx)
+	params.ProfileID = profileUUID
+	params.AuthorizationKey = authorizationKey
+	params.MetroCode = metro
+	params.Region = region
+
+	validateOK, err := m.Buyer.Connections.ValidateAuthorizationKeyUsingGET(params, token)
+	if err != nil {
+		// ECX answers invalid keys with a bad request, sellers without key validation with not found
+		if client.IsStatus(err, http.StatusBadRequest) {
+			return nil, fmt.Errorf("%w for seller profile %s: %v", ErrInvalidAuthorizationKey, profileUUID, err)
+		}
+		if client.IsStatus(err, http.StatusNotFound) {
+			return nil, fmt.Errorf("%w for seller profile %s: %v", ErrAuthorizationKeyValidationUnavailable, profileUUID, err)
+		}
+		return nil, err
+	}
+
+	if validateOK.Payload == nil || !strings.EqualFold(validateOK.Payload.Status, AuthorizationKeyValid) {
+		var status, message string
+		if validateOK.Payload != nil {
+			status, message = validateOK.Payload.Status, validateOK.Payload.Message
+		}
+		return validateOK.Payload, fmt.Errorf("%w for seller profile %s: %s", ErrInvalidAuthorizationKey, profileUUID, strings.TrimSpace(status+" "+message))
+	}
+
+	return validateOK.Payload, nil
+}
+
+// UpdateConnection calls UpdateConnectionWithContext with a background context
+func (m *ECXConnectionsAPI) UpdateConnection(uuid string, params *UpdateConnectionParams, ecxseller *ECXSellerServicesAPI) (*apiconnections.PerformUserActionUsingPATCHOK, error) {
+	return m.UpdateConnectionWithContext(context.Background(), uuid, params, ecxseller)
+}
+
+// UpdateConnectionWithContext renames a connection, changes its speed or edits its notification emails with the
+// ECX update action (PATCH /ecx/v3/l2/connections/{connId}?action=update, see updateConnectionRequest), only the
+// changed fields are sent. Speed changes are validated against the speed bands of the seller profile when ecxseller
+// is provided, returning ErrSpeedNotAllowed if the profile doesn't offer the speed
+func (m *ECXConnectionsAPI) UpdateConnectionWithContext(ctx context.Context, uuid string, params *UpdateConnectionParams, ecxseller *ECXSellerServicesAPI) (*apiconnections.PerformUserActionUsingPATCHOK, error) {
+	if params == nil {
+		return nil, errors.New("Parameters to update connection not provided")
+	}
+
+	conn, err := m.GetByUUIDWithContext(ctx, uuid)
+	if err != nil {
+		return nil, fmt.Errorf("can't obtain connection %s: %w", uuid, err)
+	}
+	current := conn.Payload
+
+	request := &updateConnectionRequest{}
+	if params.Name != "" && params.Name != current.Name {
+		request.Name = params.Name
+	}
+
+	if params.Speed != 0 {
+		unit := strings.ToUpper(params.SpeedUnit)
+		if unit == "" {
+			unit = current.SpeedUnit
+		}
+		if params.Speed != int64(current.Speed) || unit != current.SpeedUnit {
+			if ecxseller != nil && current.SellerServiceUUID != "" {
+				if err := validateSpeed(ctx, ecxseller, current.SellerServiceUUID, params.Speed, unit); err != nil {
+					return nil, err
+				}
+			}
+			request.Speed = params.Speed
+			request.SpeedUnit = unit
+		}
+	}
+
+	if params.Notifications != nil || params.AddNotifications != nil || params.RemoveNotifications != nil {
+		notifications := editNotifications(current.Notifications, params.Notifications, params.AddNotifications, params.RemoveNotifications)
+		if len(notifications) == 0 {
+			return nil, errors.New("a connection requires at least one notification email")
+		}
+		if !equalStrings(notifications, current.Notifications) {
+			request.Notifications = notifications
+		}
+	}
+
+	if request.Name == "" && request.Speed == 0 && request.Notifications == nil {
+		return nil, ErrNothingToUpdate
+	}
+
+	token, err := m.GetTokenWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := m.Submit(&runtime.ClientOperation{
+		ID:                 "updateConnectionUsingPATCH",
+		Method:             "PATCH",
+		PathPattern:        "/ecx/v3/l2/connections/{connId}",
+		ProducesMediaTypes: []string{"application/json"},
+		ConsumesMediaTypes: []string{"application/json"},
+		Schemes:            []string{"https"},
+		Params:             &updateConnectionParams{ConnID: uuid, Request: request},
+		Reader:             &apiconnections.PerformUserActionUsingPATCHReader{},
+		AuthInfo:           token,
+		Context:            ctx,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result.(*apiconnections.PerformUserActionUsingPATCHOK), nil
+}
+
+// validateSpeed checks speed is one of the speed bands of the seller profile, profiles without bands accept any speed
+func validateSpeed(ctx context.Context, ecxseller *ECXSellerServicesAPI, profileUUID string, speed int64, unit string) error {
+	seller, err := ecxseller.GetSellerProfileByUUIDWithContext(ctx, profileUUID)
+	if err != nil {
+		return fmt.Errorf("can't obtain seller profile for %s UUID: %w", profileUUID, err)
+	}
+	if seller == nil || seller.Payload == nil || len(seller.Payload.SpeedBands) == 0 {
+		return nil
+	}
+
+	mbps := speedMbps(float64(speed), unit)
+	allowed := make([]string, 0, len(seller.Payload.SpeedBands))
+	for _, band := range seller.Payload.SpeedBands {
+		if band == nil {
+			continue
+		}
+		if speedMbps(band.Speed, band.Unit) == mbps {
+			return nil
+		}
+		allowed = append(allowed, fmt.Sprintf("%g %s", band.Speed, band.Unit))
+	}
+	return fmt.Errorf("%w: %d %s, allowed speeds %s", ErrSpeedNotAllowed, speed, unit, strings.Join(allowed, ", "))
+}
+
+// speedMbps converts a speed to megabits, ECX units are MB and GB
+func speedMbps(speed float64, unit string) float64 {
+	if strings.ToUpper(unit) == "GB" {
+		return speed * 1000
+	}
+	return speed
+}
+
+// editNotifications returns replace (or current if replace is nil) with add appended and remove removed, without
+// duplicates
+func editNotifications(current, replace, add, remove []string) []string {
+	if replace == nil {
+		replace = current
+	}
+
+	seen := map[string]bool{}
+	for _, email := range remove {
+		seen[strings.ToLower(email)] = true
+	}
+
+	var notifications []string
+	for _, email := range append(append([]string{}, replace...), add...) {
+		if email == "" || seen[strings.ToLower(email)] {
+			continue
+		}
+		seen[strings.ToLower(email)] = true
+		notifications = append(notifications, email)
+	}
+	return notifications
+}
+
+// equalStrings returns true if a and b have the same elements in the same order
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// WaitForConnectionState calls WaitForConnectionStateWithContext with a background context
+func (m *ECXConnectionsAPI) WaitForConnectionState(uuid string, states []string, timeout time.Duration) (*models.GETConnectionByUUIDResponse, error) {
+	return m.WaitForConnectionStateWithContext(context.Background(), uuid, states, timeout)
+}
+
+// WaitForConnectionStateWithContext polls the connection until its status is one of states and returns it. Include
+// ConnectionStatusNotFound in states to wait for a deleted connection to disappear (the connection returned is nil
+// then). Polls are spaced by the client PollPolicy. Returns a ConnectionStatusError if a failed status is reached and
+// ErrWaitTimeout when timeout (if > 0) expires.
+func (m *ECXConnectionsAPI) WaitForConnectionStateWithContext(ctx context.Context, uuid string, states []string, timeout time.Duration) (*models.GETConnectionByUUIDResponse, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	var conn *models.GETConnectionByUUIDResponse
+	lastStatus := ""
+	err := m.Poll(ctx, func(ctx context.Context) (bool, error) {
+		resp, err := m.GetByUUIDWithContext(ctx, uuid)
+		switch {
+		case err == nil:
+			conn = resp.Payload
+			lastStatus = conn.Status
+			if m.Debug {
+				log.Printf("Connection %s status %s\n", uuid, lastStatus)
+			}
+			if containsStatus(states, lastStatus) {
+				return true, nil
+			}
+			if containsStatus(ConnectionFailedStatuses, lastStatus) {
+				return true, &ConnectionStatusError{UUID: uuid, Status: lastStatus}
+			}
+			return false, nil
+		case client.IsStatus(err, http.StatusNotFound) && containsStatus(states, ConnectionStatusNotFound):
+			conn = nil
+			return true, nil
+		}
+		return false, err
+	})
+
+	var statusErr *ConnectionStatusError
+	switch {
+	case err == nil, errors.As(err, &statusErr):
+		return conn, err
+	case err == context.DeadlineExceeded:
+		return nil, fmt.Errorf("%w: connection %s last status %q after %s", ErrWaitTimeout, uuid, lastStatus, timeout)
+	}
+	return nil, err
+}
+
+// containsStatus returns true if status is in statuses
+func containsStatus(statuses []string, status string) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
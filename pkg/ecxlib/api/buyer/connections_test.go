@@ -1 +1,279 @@
 package buyer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	client "github.com/jxoir/equinix-tools/pkg/ecxlib/api/client"
+	"github.com/jxoir/go-ecxfabric/buyer/models"
+)
+
+func TestConnectionsResponseFilterItems(t *testing.T) {
+	resp := ConnectionsResponse{}
+	resp.Items = []*models.GetBuyerConResContent{
+		{UUID: "07c8a274-4e80-4662-8cb9-636b8b00eb26", Name: "EQUINIX_TEST", Speed: 50},
+		{UUID: "9350fd44-0883-4aaa-b266-613d33dd0c95", Name: "OTHER", Speed: 100},
+	}
+
+	resp.FilterItems(map[string]string{"name": "EQUINIX"})
+
+	if resp.Count() != 1 {
+		t.Fatalf("Expected 1 item after filtering, received %d", resp.Count())
+	}
+	if resp.Items[0].UUID != "07c8a274-4e80-4662-8cb9-636b8b00eb26" {
+		t.Errorf("Expected EQUINIX_TEST connection, received %s", resp.Items[0].Name)
+	}
+}
+
+func TestConnectionsResponseSetItemsPanicsOnOtherTypes(t *testing.T) {
+	resp := ConnectionsResponse{}
+	resp.SetItems([]interface{}{&models.GetBuyerConResContent{Name: "EQUINIX_TEST"}})
+	if resp.Count() != 1 {
+		t.Errorf("Expected 1 item, received %d", resp.Count())
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected a panic setting a *models.SellerService item")
+		}
+	}()
+	resp.SetItems([]interface{}{&models.SellerService{}})
+}
+
+// newTestClient returns a client against a mocked ECX serving mux, with the oauth endpoint already handled
+func newTestClient(mux *http.ServeMux) (*client.EquinixAPIClient, *httptest.Server) {
+	mux.HandleFunc("/oauth2/v1/token", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token":"t1","token_timeout":"3600"}`)
+	})
+	server := httptest.NewTLSServer(mux)
+
+	endpoint := strings.TrimPrefix(server.URL, "https://")
+	ec := client.NewEcxAPIClient(&client.EquinixAPIParams{
+		AppID:     "appid",
+		AppSecret: "secret",
+		GrantType: "client_credentials",
+		Endpoint:  endpoint,
+	}, endpoint, true)
+	return ec, server
+}
+
+// newConnectionsTestAPI returns an ECXConnectionsAPI against a mocked ECX answering connection uuid with the next
+// status in statuses on every request, a 404 once they are exhausted
+func newConnectionsTestAPI(uuid string, statuses []string) (*ECXConnectionsAPI, *httptest.Server) {
+	var calls int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ecx/v3/l2/connections/"+uuid, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		n := int(atomic.AddInt32(&calls, 1)) - 1
+		if n >= len(statuses) {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"errorCode":"IC-LAYER2-4004","errorMessage":"Connection not found"}`)
+			return
+		}
+		fmt.Fprintf(w, `{"uuid":%q,"status":%q}`, uuid, statuses[n])
+	})
+	ec, server := newTestClient(mux)
+	ec.PollPolicy = &client.PollPolicy{Interval: time.Millisecond}
+
+	// positional literal, as library callers build it
+	return &ECXConnectionsAPI{ec}, server
+}
+
+func TestWaitForConnectionState(t *testing.T) {
+	uuid := "07c8a274-4e80-4662-8cb9-636b8b00eb26"
+	api, server := newConnectionsTestAPI(uuid, []string{"PROVISIONING", "PROVISIONING", "PROVISIONED"})
+	defer server.Close()
+
+	conn, err := api.WaitForConnectionState(uuid, []string{ConnectionStatusProvisioned}, time.Second)
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	if conn.Status != ConnectionStatusProvisioned {
+		t.Errorf("Expected PROVISIONED, received %s", conn.Status)
+	}
+}
+
+func TestWaitForConnectionStateFailed(t *testing.T) {
+	uuid := "07c8a274-4e80-4662-8cb9-636b8b00eb26"
+	api, server := newConnectionsTestAPI(uuid, []string{"PROVISIONING", "REJECTED"})
+	defer server.Close()
+
+	_, err := api.WaitForConnectionState(uuid, []string{ConnectionStatusProvisioned}, time.Second)
+	if statusErr, ok := err.(*ConnectionStatusError); !ok || statusErr.Status != "REJECTED" {
+		t.Errorf("Expected ConnectionStatusError with REJECTED status, received %v", err)
+	}
+}
+
+func TestWaitForConnectionStateNotFound(t *testing.T) {
+	uuid := "07c8a274-4e80-4662-8cb9-636b8b00eb26"
+	api, server := newConnectionsTestAPI(uuid, []string{"DEPROVISIONING"})
+	defer server.Close()
+
+	conn, err := api.WaitForConnectionState(uuid, []string{ConnectionStatusDeprovisioned, ConnectionStatusNotFound}, time.Second)
+	if err != nil || conn != nil {
+		t.Errorf("Expected deleted connection, received %v %v", conn, err)
+	}
+}
+
+func TestWaitForConnectionStateTimeout(t *testing.T) {
+	uuid := "07c8a274-4e80-4662-8cb9-636b8b00eb26"
+	statuses := make([]string, 1000)
+	for i := range statuses {
+		statuses[i] = "PROVISIONING"
+	}
+	api, server := newConnectionsTestAPI(uuid, statuses)
+	defer server.Close()
+
+	_, err := api.WaitForConnectionState(uuid, []string{ConnectionStatusProvisioned}, 50*time.Millisecond)
+	if !errors.Is(err, ErrWaitTimeout) {
+		t.Errorf("Expected ErrWaitTimeout, received %v", err)
+	}
+}
+
+// newUpdateTestAPI returns connections and seller APIs against a mocked ECX with a 50 MB connection to a seller
+// profile offering 50 MB, 100 MB and 1 GB, the body of the PATCH request is stored in patched
+func newUpdateTestAPI(patched *map[string]interface{}) (*ECXConnectionsAPI, *ECXSellerServicesAPI, *httptest.Server) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ecx/v3/l2/connections/07c8a274-4e80-4662-8cb9-636b8b00eb26", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodPatch {
+			if r.URL.Query().Get("action") != "update" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			json.NewDecoder(r.Body).Decode(patched)
+			fmt.Fprint(w, `{"message":"Connection updated","primaryConnectionId":"07c8a274-4e80-4662-8cb9-636b8b00eb26"}`)
+			return
+		}
+		fmt.Fprint(w, `{"uuid":"07c8a274-4e80-4662-8cb9-636b8b00eb26","name":"EQUINIX_TEST","speed":50,"speedUnit":"MB",
+			"sellerServiceUUID":"9350fd44-0883-4aaa-b266-613d33dd0c95","notifications":["noc@example.com"]}`)
+	})
+	mux.HandleFunc("/ecx/v3/l2/serviceprofiles/9350fd44-0883-4aaa-b266-613d33dd0c95", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"uuid":"9350fd44-0883-4aaa-b266-613d33dd0c95","speedBands":[{"speed":50,"unit":"MB"},{"speed":100,"unit":"MB"},{"speed":1,"unit":"GB"}]}`)
+	})
+	ec, server := newTestClient(mux)
+
+	return NewECXConnectionsAPI(ec), NewECXSellerServicesAPI(ec), server
+}
+
+func TestUpdateConnection(t *testing.T) {
+	patched := map[string]interface{}{}
+	api, seller, server := newUpdateTestAPI(&patched)
+	defer server.Close()
+
+	_, err := api.UpdateConnection("07c8a274-4e80-4662-8cb9-636b8b00eb26", &UpdateConnectionParams{
+		Name:                "EQUINIX_RENAMED",
+		Speed:               1,
+		SpeedUnit:           "gb",
+		AddNotifications:    []string{"ops@example.com"},
+		RemoveNotifications: []string{"NOC@example.com"},
+	}, seller)
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+
+	expected := map[string]interface{}{
+		"name":          "EQUINIX_RENAMED",
+		"speed":         float64(1),
+		"speedUnit":     "GB",
+		"notifications": []interface{}{"ops@example.com"},
+	}
+	if !reflect.DeepEqual(patched, expected) {
+		t.Errorf("Expected request %v, received %v", expected, patched)
+	}
+}
+
+func TestUpdateConnectionSpeedNotAllowed(t *testing.T) {
+	patched := map[string]interface{}{}
+	api, seller, server := newUpdateTestAPI(&patched)
+	defer server.Close()
+
+	_, err := api.UpdateConnection("07c8a274-4e80-4662-8cb9-636b8b00eb26", &UpdateConnectionParams{Speed: 200}, seller)
+	if !errors.Is(err, ErrSpeedNotAllowed) {
+		t.Errorf("Expected ErrSpeedNotAllowed, received %v", err)
+	}
+	if len(patched) != 0 {
+		t.Errorf("Expected no update request, received %v", patched)
+	}
+}
+
+func TestUpdateConnectionNothingToUpdate(t *testing.T) {
+	patched := map[string]interface{}{}
+	api, seller, server := newUpdateTestAPI(&patched)
+	defer server.Close()
+
+	_, err := api.UpdateConnection("07c8a274-4e80-4662-8cb9-636b8b00eb26", &UpdateConnectionParams{Name: "EQUINIX_TEST", Speed: 50}, seller)
+	if err != ErrNothingToUpdate {
+		t.Errorf("Expected ErrNothingToUpdate, received %v", err)
+	}
+}
+
+// newValidateTestAPI returns an ECXConnectionsAPI against a mocked ECX accepting key as the only valid
+// authorization key, other keys are rejected with a bad request. Seller profile "unsupported" answers not found.
+func newValidateTestAPI(key string) (*ECXConnectionsAPI, *httptest.Server) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ecx/v3/l2/connections/validateAuthorizationKey", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		q := r.URL.Query()
+		if q.Get("profileId") == "unsupported" {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"errorCode":"IC-LAYER2-4004","errorMessage":"Not found"}`)
+			return
+		}
+		if q.Get("authorizationKey") != key || q.Get("metroCode") == "" || q.Get("profileId") == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `[{"errorCode":"IC-LAYER2-4021","errorMessage":"Invalid authorization key"}]`)
+			return
+		}
+		fmt.Fprint(w, `{"message":"Authorization key provided is valid","status":"VALID"}`)
+	})
+	ec, server := newTestClient(mux)
+
+	return NewECXConnectionsAPI(ec), server
+}
+
+func TestValidateAuthorizationKey(t *testing.T) {
+	api, server := newValidateTestAPI("123456789012")
+	defer server.Close()
+
+	res, err := api.ValidateAuthorizationKey("9350fd44-0883-4aaa-b266-613d33dd0c95", "123456789012", "LD", "eu-west-2")
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	if res.Status != AuthorizationKeyValid {
+		t.Errorf("Expected VALID status, received %s", res.Status)
+	}
+}
+
+func TestValidateAuthorizationKeyInvalid(t *testing.T) {
+	api, server := newValidateTestAPI("123456789012")
+	defer server.Close()
+
+	_, err := api.ValidateAuthorizationKey("9350fd44-0883-4aaa-b266-613d33dd0c95", "000000000000", "LD", "")
+	if !errors.Is(err, ErrInvalidAuthorizationKey) {
+		t.Errorf("Expected ErrInvalidAuthorizationKey, received %v", err)
+	}
+	if err == nil || !strings.Contains(err.Error(), "IC-LAYER2-4021: Invalid authorization key [status 400]") {
+		t.Errorf("Expected the ECX bad request in the message, received %v", err)
+	}
+}
+
+func TestValidateAuthorizationKeyUnavailable(t *testing.T) {
+	api, server := newValidateTestAPI("123456789012")
+	defer server.Close()
+
+	_, err := api.ValidateAuthorizationKey("unsupported", "123456789012", "LD", "")
+	if !errors.Is(err, ErrAuthorizationKeyValidationUnavailable) || errors.Is(err, ErrInvalidAuthorizationKey) {
+		t.Errorf("Expected ErrAuthorizationKeyValidationUnavailable, received %v", err)
+	}
+}
@@ -1,45 +1,57 @@
 package buyer
 
 import (
-	"fmt"
+	"context"
 	"log"
 
 	api "github.com/jxoir/equinix-tools/pkg/ecxlib/api/client"
 	apimetros "github.com/jxoir/go-ecxfabric/buyer/client/metros"
+	"github.com/jxoir/go-ecxfabric/buyer/models"
 )
 
 type MetrosAPIHandler interface {
-	GetAllMetros() (*apimetros.GetMetrosUsingGETOK, error)
+	GetAllMetros() (*MetrosResponse, error)
+	GetAllMetrosWithContext(ctx context.Context) (*MetrosResponse, error)
 }
 
 type ECXMetrosAPI struct {
 	*api.EquinixAPIClient
 }
 
+// MetrosResponse wrapper for swagger GETCommonMetroRespItems0 list
+type MetrosResponse struct {
+	api.Collection[*models.GETCommonMetroRespItems0]
+}
+
 // NewECXMetrosAPI returns instantiated ECXMetrosAPI struct
 func NewECXMetrosAPI(equinixAPIClient *api.EquinixAPIClient) *ECXMetrosAPI {
 	return &ECXMetrosAPI{equinixAPIClient}
 }
 
-// GetAllMetros returns array of GetMetrosUsingGETOK with list of customer connections
-func (ec *ECXMetrosAPI) GetAllMetros() (*apimetros.GetMetrosUsingGETOK, error) {
-	token, err := ec.GetToken()
+// GetAllMetros calls GetAllMetrosWithContext with a background context
+func (ec *ECXMetrosAPI) GetAllMetros() (*MetrosResponse, error) {
+	return ec.GetAllMetrosWithContext(context.Background())
+}
+
+// GetAllMetrosWithContext returns the list of metros available to the customer
+func (ec *ECXMetrosAPI) GetAllMetrosWithContext(ctx context.Context) (*MetrosResponse, error) {
+	token, err := ec.GetTokenWithContext(ctx)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
-	respMetrosOk, _, err := ec.Buyer.Metros.GetMetrosUsingGET(nil, token)
+	respMetrosOk, respMetrosNC, err := ec.Buyer.Metros.GetMetrosUsingGET(apimetros.NewGetMetrosUsingGETParamsWithContext(ctx), token)
 	if err != nil {
-		switch t := err.(type) {
-		default:
-			log.Fatal(err)
-		case *apimetros.GetMetrosUsingGETNoContent:
-			if ec.Debug {
-				fmt.Println(t.Error())
-			}
-			return nil, err
-		}
+		return nil, err
+	}
+	if respMetrosNC != nil && ec.Debug {
+		log.Println(respMetrosNC.Error())
+	}
+
+	metrosList := &MetrosResponse{}
+	if respMetrosOk != nil {
+		metrosList.Items = respMetrosOk.Payload
 	}
 
-	return respMetrosOk, nil
+	return metrosList, nil
 
 }
@@ -0,0 +1,320 @@
+package buyer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jxoir/go-ecxfabric/buyer/models"
+)
+
+// ConnectionManifest desired connections, as read from an ecxctl apply file
+type ConnectionManifest struct {
+	Connections []*ConnectionSpec `yaml:"connections"`
+}
+
+// ConnectionSpec desired state of a connection, identified by Name, PortUUID and VlanSTag
+type ConnectionSpec struct {
+	Name     string `yaml:"name"`
+	PortUUID string `yaml:"portUUID"`
+	VlanSTag int64  `yaml:"vlanSTag"`
+	VlanCTag string `yaml:"vlanCTag,omitempty"`
+
+	SellerProfileUUID   string `yaml:"sellerProfileUUID"`
+	SellerMetroCode     string `yaml:"sellerMetroCode,omitempty"`
+	SellerRegion        string `yaml:"sellerRegion,omitempty"`
+	AuthorizationKey    string `yaml:"authorizationKey,omitempty"`
+	NamedTag            string `yaml:"namedTag,omitempty"`
+	PurchaseOrderNumber string `yaml:"purchaseOrderNumber,omitempty"`
+
+	Speed     int64  `yaml:"speed"`
+	SpeedUnit string `yaml:"speedUnit"`
+	// Notifications nil leaves the notification emails of existing connections unmanaged
+	Notifications []string `yaml:"notifications,omitempty"`
+
+	// Secondary connection of a redundant pair, identified by its own name, port and vlan
+	Secondary *SecondaryConnectionSpec `yaml:"secondary,omitempty"`
+}
+
+// SecondaryConnectionSpec secondary side of a redundant ConnectionSpec, sharing speed, seller and notifications
+type SecondaryConnectionSpec struct {
+	Name     string `yaml:"name"`
+	PortUUID string `yaml:"portUUID"`
+	VlanSTag int64  `yaml:"vlanSTag"`
+	VlanCTag string `yaml:"vlanCTag,omitempty"`
+}
+
+// PlanAction kind of change of a PlannedChange
+type PlanAction string
+
+// Plan actions
+const (
+	PlanCreate PlanAction = "create"
+	PlanUpdate PlanAction = "update"
+	PlanDelete PlanAction = "delete"
+)
+
+// inactiveStatuses connections being (or already) deleted, ignored when planning
+var inactiveStatuses = []string{"DEPROVISIONING", ConnectionStatusDeprovisioned}
+
+// FieldDiff a field changed by an update
+type FieldDiff struct {
+	Field string
+	From  string
+	To    string
+}
+
+// PlannedChange a change needed to reach the manifest, Spec is nil on deletes and Current on creates
+type PlannedChange struct {
+	Action  PlanAction
+	Name    string
+	Spec    *ConnectionSpec
+	Current *models.GetBuyerConResContent
+	Update  *UpdateConnectionParams
+	Diff    []FieldDiff
+}
+
+// ConnectionPlan changes to apply, in order
+type ConnectionPlan struct {
+	Changes []*PlannedChange
+}
+
+// Count returns the number of changes of action
+func (p *ConnectionPlan) Count(action PlanAction) int {
+	count := 0
+	for _, change := range p.Changes {
+		if change.Action == action {
+			count++
+		}
+	}
+	return count
+}
+
+// Empty returns true if the connections already match the manifest
+func (p *ConnectionPlan) Empty() bool {
+	return len(p.Changes) == 0
+}
+
+// connectionKey identity of a connection in a manifest
+func connectionKey(name string, portUUID string, vlanSTag int64) string {
+	return fmt.Sprintf("%s/%s/%d", name, portUUID, vlanSTag)
+}
+
+// Validate checks the fields required to create the connection are set
+func (s *ConnectionSpec) Validate() error {
+	switch {
+	case s.Name == "":
+		return errors.New("connection without name")
+	case s.PortUUID == "":
+		return fmt.Errorf("connection %s: portUUID required", s.Name)
+	case s.VlanSTag == 0:
+		return fmt.Errorf("connection %s: vlanSTag required", s.Name)
+	case s.SellerProfileUUID == "":
+		return fmt.Errorf("connection %s: sellerProfileUUID required", s.Name)
+	case s.Speed == 0 || s.SpeedUnit == "":
+		return fmt.Errorf("connection %s: speed and speedUnit required", s.Name)
+	}
+
+	if s.Secondary != nil {
+		switch {
+		case s.Secondary.Name == "":
+			return fmt.Errorf("connection %s: secondary without name", s.Name)
+		case s.Secondary.PortUUID == "":
+			return fmt.Errorf("connection %s: secondary portUUID required", s.Name)
+		case s.Secondary.VlanSTag == 0:
+			return fmt.Errorf("connection %s: secondary vlanSTag required", s.Name)
+		case s.Secondary.PortUUID == s.PortUUID:
+			return fmt.Errorf("connection %s: secondary must use a different port", s.Name)
+		}
+	}
+	return nil
+}
+
+// CreateParams returns the params creating the connection (and its secondary)
+func (s *ConnectionSpec) CreateParams() *CreateL2ConnectionParams {
+	params := &CreateL2ConnectionParams{
+		PrimaryName:         s.Name,
+		PrimaryPortUUID:     s.PortUUID,
+		PrimaryVlanSTag:     s.VlanSTag,
+		PrimaryVlanCTag:     s.VlanCTag,
+		ProfileUUID:         s.SellerProfileUUID,
+		SellerMetroCode:     s.SellerMetroCode,
+		SellerRegion:        s.SellerRegion,
+		AuthorizationKey:    s.AuthorizationKey,
+		NamedTag:            s.NamedTag,
+		PurchaseOrderNumber: s.PurchaseOrderNumber,
+		Speed:               s.Speed,
+		SpeedUnit:           s.SpeedUnit,
+		Notifications:       s.Notifications,
+	}
+	if s.Secondary != nil {
+		params.SecondaryName = s.Secondary.Name
+		params.SecondaryPortUUID = s.Secondary.PortUUID
+		params.SecondaryVlanSTag = s.Secondary.VlanSTag
+		params.SecondaryVlanCTag = s.Secondary.VlanCTag
+	}
+	return params
+}
+
+// PlanConnections compares specs with the current connections and returns the changes needed to reach them: creates
+// for missing connections and updates for speed or notification changes. With prune, active connections not in specs
+// are deleted, only on the ports (a-side) used by specs so connections on other ports are never touched. Changes ECX
+// can't apply in place (seller profile, secondary of an existing connection) are errors.
+func PlanConnections(specs []*ConnectionSpec, current []*models.GetBuyerConResContent, prune bool) (*ConnectionPlan, error) {
+	existing := map[string]*models.GetBuyerConResContent{}
+	for _, conn := range current {
+		if conn == nil || containsStatus(inactiveStatuses, conn.Status) {
+			continue
+		}
+		existing[connectionKey(conn.Name, conn.PortUUID, conn.VlanSTag)] = conn
+	}
+
+	plan := &ConnectionPlan{}
+	managed := map[string]bool{}
+	managedPorts := map[string]bool{}
+	for _, spec := range specs {
+		if err := spec.Validate(); err != nil {
+			return nil, err
+		}
+		managedPorts[spec.PortUUID] = true
+		if spec.Secondary != nil {
+			managedPorts[spec.Secondary.PortUUID] = true
+		}
+
+		keys := []string{connectionKey(spec.Name, spec.PortUUID, spec.VlanSTag)}
+		names := []string{spec.Name}
+		if spec.Secondary != nil {
+			keys = append(keys, connectionKey(spec.Secondary.Name, spec.Secondary.PortUUID, spec.Secondary.VlanSTag))
+			names = append(names, spec.Secondary.Name)
+		}
+		for _, key := range keys {
+			if managed[key] {
+				return nil, fmt.Errorf("connection %s declared more than once", key)
+			}
+			managed[key] = true
+		}
+
+		primary := existing[keys[0]]
+		if primary == nil {
+			if len(keys) > 1 && existing[keys[1]] != nil {
+				return nil, fmt.Errorf("connection %s: primary missing but secondary %s exists, delete it first", spec.Name, spec.Secondary.Name)
+			}
+			plan.Changes = append(plan.Changes, &PlannedChange{Action: PlanCreate, Name: spec.Name, Spec: spec})
+			continue
+		}
+
+		for i, key := range keys {
+			conn := existing[key]
+			if conn == nil {
+				return nil, fmt.Errorf("connection %s: secondary %s can't be added to an existing connection, delete it first", spec.Name, names[i])
+			}
+			if conn.SellerServiceUUID != "" && conn.SellerServiceUUID != spec.SellerProfileUUID {
+				return nil, fmt.Errorf("connection %s: seller profile can't be changed from %s to %s, delete it first", names[i], conn.SellerServiceUUID, spec.SellerProfileUUID)
+			}
+			if change := planUpdate(spec, conn); change != nil {
+				plan.Changes = append(plan.Changes, change)
+			}
+		}
+	}
+
+	if prune {
+		var deletes []*PlannedChange
+		for key, conn := range existing {
+			if !managed[key] && managedPorts[conn.PortUUID] {
+				deletes = append(deletes, &PlannedChange{Action: PlanDelete, Name: conn.Name, Current: conn})
+			}
+		}
+		// map order is random, keep plans stable
+		sort.Slice(deletes, func(i, j int) bool {
+			return deletes[i].Name < deletes[j].Name || deletes[i].Name == deletes[j].Name && deletes[i].Current.UUID < deletes[j].Current.UUID
+		})
+		plan.Changes = append(plan.Changes, deletes...)
+	}
+
+	return plan, nil
+}
+
+// planUpdate returns the update of conn to match spec, nil if there are no differences
+func planUpdate(spec *ConnectionSpec, conn *models.GetBuyerConResContent) *PlannedChange {
+	update := &UpdateConnectionParams{}
+	var diff []FieldDiff
+
+	if speedMbps(float64(spec.Speed), spec.SpeedUnit) != speedMbps(float64(conn.Speed), conn.SpeedUnit) {
+		update.Speed = spec.Speed
+		update.SpeedUnit = strings.ToUpper(spec.SpeedUnit)
+		diff = append(diff, FieldDiff{
+			Field: "speed",
+			From:  fmt.Sprintf("%d %s", conn.Speed, conn.SpeedUnit),
+			To:    fmt.Sprintf("%d %s", update.Speed, update.SpeedUnit),
+		})
+	}
+
+	if spec.Notifications != nil && !sameEmails(spec.Notifications, conn.Notifications) {
+		update.Notifications = spec.Notifications
+		diff = append(diff, FieldDiff{
+			Field: "notifications",
+			From:  strings.Join(conn.Notifications, ","),
+			To:    strings.Join(spec.Notifications, ","),
+		})
+	}
+
+	if len(diff) == 0 {
+		return nil
+	}
+	return &PlannedChange{Action: PlanUpdate, Name: conn.Name, Spec: spec, Current: conn, Update: update, Diff: diff}
+}
+
+// sameEmails returns true if a and b hold the same emails in any order and case
+func sameEmails(a, b []string) bool {
+	normalize := func(emails []string) []string {
+		normalized := make([]string, len(emails))
+		for i, email := range emails {
+			normalized[i] = strings.ToLower(email)
+		}
+		sort.Strings(normalized)
+		return normalized
+	}
+	return equalStrings(normalize(a), normalize(b))
+}
+
+// PlanResult outcome of applying a PlannedChange, UUIDs of the connections created, updated or deleted
+type PlanResult struct {
+	Change *PlannedChange
+	UUIDs  []string
+	Err    error
+}
+
+// ApplyPlan calls ApplyPlanWithContext with a background context
+func (m *ECXConnectionsAPI) ApplyPlan(plan *ConnectionPlan, ecxseller *ECXSellerServicesAPI) []*PlanResult {
+	return m.ApplyPlanWithContext(context.Background(), plan, ecxseller)
+}
+
+// ApplyPlanWithContext applies the changes of plan in order, a failed change doesn't stop the following ones.
+// ecxseller validates speed changes, see UpdateConnectionWithContext.
+func (m *ECXConnectionsAPI) ApplyPlanWithContext(ctx context.Context, plan *ConnectionPlan, ecxseller *ECXSellerServicesAPI) []*PlanResult {
+	results := make([]*PlanResult, 0, len(plan.Changes))
+	for _, change := range plan.Changes {
+		result := &PlanResult{Change: change}
+		switch change.Action {
+		case PlanCreate:
+			conn, err := m.CreateL2ConnectionWithContext(ctx, change.Spec.CreateParams())
+			result.Err = err
+			if err == nil {
+				result.UUIDs = append(result.UUIDs, conn.Payload.PrimaryConnectionID)
+				if conn.Payload.SecondaryConnectionID != "" {
+					result.UUIDs = append(result.UUIDs, conn.Payload.SecondaryConnectionID)
+				}
+			}
+		case PlanUpdate:
+			result.UUIDs = []string{change.Current.UUID}
+			_, result.Err = m.UpdateConnectionWithContext(ctx, change.Current.UUID, change.Update, ecxseller)
+		case PlanDelete:
+			result.UUIDs = []string{change.Current.UUID}
+			_, result.Err = m.DeleteByUUIDWithContext(ctx, change.Current.UUID)
+		}
+		results = append(results, result)
+	}
+	return results
+}
@@ -0,0 +1,91 @@
+package buyer
+
+import (
+	"testing"
+
+	"github.com/jxoir/go-ecxfabric/buyer/models"
+)
+
+func TestPlanConnections(t *testing.T) {
+	specs := []*ConnectionSpec{
+		{Name: "NEW", PortUUID: "port-1", VlanSTag: 100, SellerProfileUUID: "seller-1", Speed: 50, SpeedUnit: "MB"},
+		{Name: "FASTER", PortUUID: "port-1", VlanSTag: 200, SellerProfileUUID: "seller-1", Speed: 1, SpeedUnit: "GB",
+			Notifications: []string{"noc@example.com"}},
+		{Name: "SAME", PortUUID: "port-1", VlanSTag: 300, SellerProfileUUID: "seller-1", Speed: 100, SpeedUnit: "MB",
+			Notifications: []string{"NOC@example.com", "ops@example.com"}},
+	}
+	current := []*models.GetBuyerConResContent{
+		{UUID: "uuid-2", Name: "FASTER", PortUUID: "port-1", VlanSTag: 200, SellerServiceUUID: "seller-1", Speed: 500, SpeedUnit: "MB",
+			Notifications: []string{"noc@example.com"}, Status: "PROVISIONED"},
+		{UUID: "uuid-3", Name: "SAME", PortUUID: "port-1", VlanSTag: 300, SellerServiceUUID: "seller-1", Speed: 100, SpeedUnit: "MB",
+			Notifications: []string{"ops@example.com", "noc@example.com"}, Status: "PROVISIONED"},
+		{UUID: "uuid-4", Name: "UNMANAGED", PortUUID: "port-1", VlanSTag: 400, Status: "PROVISIONED"},
+		{UUID: "uuid-5", Name: "NEW", PortUUID: "port-1", VlanSTag: 100, Status: ConnectionStatusDeprovisioned},
+		{UUID: "uuid-6", Name: "OTHER_PORT", PortUUID: "port-9", ZSidePortUUID: "port-1", VlanSTag: 600, Status: "PROVISIONED"},
+	}
+
+	plan, err := PlanConnections(specs, current, false)
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	if len(plan.Changes) != 2 {
+		t.Fatalf("Expected 2 changes, received %d", len(plan.Changes))
+	}
+	if plan.Changes[0].Action != PlanCreate || plan.Changes[0].Name != "NEW" {
+		t.Errorf("Expected create of NEW, received %s %s", plan.Changes[0].Action, plan.Changes[0].Name)
+	}
+	update := plan.Changes[1]
+	if update.Action != PlanUpdate || update.Current.UUID != "uuid-2" || update.Update.Speed != 1 || update.Update.SpeedUnit != "GB" {
+		t.Errorf("Expected speed update of uuid-2, received %+v", update)
+	}
+	if len(update.Diff) != 1 || update.Diff[0].Field != "speed" {
+		t.Errorf("Expected speed diff, received %+v", update.Diff)
+	}
+
+	plan, err = PlanConnections(specs, current, true)
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	// uuid-6 isn't on a port of the manifest, prune leaves it alone
+	if plan.Count(PlanDelete) != 1 || plan.Changes[2].Current.UUID != "uuid-4" {
+		t.Errorf("Expected delete of uuid-4 only with prune, received %+v", plan.Changes)
+	}
+}
+
+func TestPlanConnectionsErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		specs   []*ConnectionSpec
+		current []*models.GetBuyerConResContent
+	}{
+		{
+			name:  "missing vlan",
+			specs: []*ConnectionSpec{{Name: "A", PortUUID: "port-1", SellerProfileUUID: "seller-1", Speed: 50, SpeedUnit: "MB"}},
+		},
+		{
+			name: "duplicated",
+			specs: []*ConnectionSpec{
+				{Name: "A", PortUUID: "port-1", VlanSTag: 100, SellerProfileUUID: "seller-1", Speed: 50, SpeedUnit: "MB"},
+				{Name: "A", PortUUID: "port-1", VlanSTag: 100, SellerProfileUUID: "seller-1", Speed: 50, SpeedUnit: "MB"},
+			},
+		},
+		{
+			name:    "seller profile changed",
+			specs:   []*ConnectionSpec{{Name: "A", PortUUID: "port-1", VlanSTag: 100, SellerProfileUUID: "seller-2", Speed: 50, SpeedUnit: "MB"}},
+			current: []*models.GetBuyerConResContent{{UUID: "uuid-1", Name: "A", PortUUID: "port-1", VlanSTag: 100, SellerServiceUUID: "seller-1"}},
+		},
+		{
+			name: "secondary added",
+			specs: []*ConnectionSpec{{Name: "A", PortUUID: "port-1", VlanSTag: 100, SellerProfileUUID: "seller-1", Speed: 50, SpeedUnit: "MB",
+				Secondary: &SecondaryConnectionSpec{Name: "A-SEC", PortUUID: "port-2", VlanSTag: 100}}},
+			current: []*models.GetBuyerConResContent{{UUID: "uuid-1", Name: "A", PortUUID: "port-1", VlanSTag: 100, SellerServiceUUID: "seller-1",
+				Speed: 50, SpeedUnit: "MB"}},
+		},
+	}
+
+	for _, test := range tests {
+		if _, err := PlanConnections(test.specs, test.current, false); err == nil {
+			t.Errorf("%s: expected error", test.name)
+		}
+	}
+}
@@ -1,45 +1,136 @@
 package buyer
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"strconv"
 
 	api "github.com/jxoir/equinix-tools/pkg/ecxlib/api/client"
 	apiports "github.com/jxoir/go-ecxfabric/buyer/client/ports"
+	"github.com/jxoir/go-ecxfabric/buyer/models"
 )
 
 type PortsAPIHandler interface {
-	GetAllMetros() (*apiports.GetPortInfoUsingGET2OK, error)
+	GetAllPorts() (*PortsResponse, error)
+	GetAllPortsWithContext(ctx context.Context) (*PortsResponse, error)
+	GetPorts(filters *PortFilters) (*PortsResponse, error)
+	GetPortsWithContext(ctx context.Context, filters *PortFilters) (*PortsResponse, error)
+	GetPortByUUID(uuid string) (*models.UserPortResObj, error)
+	GetPortByUUIDWithContext(ctx context.Context, uuid string) (*models.UserPortResObj, error)
+	GetPortByName(name string) (*models.UserPortResObj, error)
+	GetPortByNameWithContext(ctx context.Context, name string) (*models.UserPortResObj, error)
+}
+
+// ErrPortNotFound returned when no buyer port has the requested uuid or name
+var ErrPortNotFound = errors.New("port not found")
+
+// PortFilters filters of GetPorts, every non empty filter must match
+type PortFilters struct {
+	// MetroCode exact port metro (ex.: LD)
+	MetroCode string
+	// ProvisionStatus exact port status (ex.: PROVISIONED)
+	ProvisionStatus string
+	// TotalBandwidth exact port bandwidth in bps
+	TotalBandwidth int64
 }
 
 type ECXPortsAPI struct {
 	*api.EquinixAPIClient
 }
 
+// PortsResponse wrapper for swagger UserPortResObj list
+type PortsResponse struct {
+	api.Collection[*models.UserPortResObj]
+}
+
 // NewECXPortsAPI returns instantiated ECXMetrosAPI struct
 func NewECXPortsAPI(equinixAPIClient *api.EquinixAPIClient) *ECXPortsAPI {
 	return &ECXPortsAPI{equinixAPIClient}
 }
 
-// GetAllPorts returns array of ports
-func (ec *ECXPortsAPI) GetAllPorts() (*apiports.GetPortInfoUsingGET2OK, error) {
-	token, err := ec.GetToken()
+// GetAllPorts calls GetAllPortsWithContext with a background context
+func (ec *ECXPortsAPI) GetAllPorts() (*PortsResponse, error) {
+	return ec.GetAllPortsWithContext(context.Background())
+}
+
+// GetAllPortsWithContext returns array of ports
+func (ec *ECXPortsAPI) GetAllPortsWithContext(ctx context.Context) (*PortsResponse, error) {
+	token, err := ec.GetTokenWithContext(ctx)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
-	respPortsOk, err := ec.Buyer.Ports.GetPortInfoUsingGET2(nil, token)
+	respPortsOk, err := ec.Buyer.Ports.GetPortInfoUsingGET2(apiports.NewGetPortInfoUsingGET2ParamsWithContext(ctx), token)
 	if err != nil {
-		switch t := err.(type) {
-		default:
-			log.Fatal(err)
-		case *apiports.GetPortInfoUsingGET2NotFound:
-			if ec.Debug {
-				fmt.Println(t.Error())
-			}
-			return nil, err
+		if ec.Debug {
+			log.Println(err.Error())
 		}
+		return nil, err
+	}
+
+	return &PortsResponse{api.Collection[*models.UserPortResObj]{Items: respPortsOk.Payload}}, nil
+
+}
+
+// GetPorts calls GetPortsWithContext with a background context
+func (ec *ECXPortsAPI) GetPorts(filters *PortFilters) (*PortsResponse, error) {
+	return ec.GetPortsWithContext(context.Background(), filters)
+}
+
+// GetPortsWithContext returns the ports matching filters, all of them if filters is nil
+func (ec *ECXPortsAPI) GetPortsWithContext(ctx context.Context, filters *PortFilters) (*PortsResponse, error) {
+	ports, err := ec.GetAllPortsWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if filters == nil {
+		return ports, nil
 	}
 
-	return respPortsOk, nil
+	// apply filters one by one so all must match
+	if filters.MetroCode != "" {
+		api.ResponseFilterExpression(ports, api.EqualFilter("metroCode", filters.MetroCode))
+	}
+	if filters.ProvisionStatus != "" {
+		api.ResponseFilterExpression(ports, api.EqualFilter("provisionStatus", filters.ProvisionStatus))
+	}
+	if filters.TotalBandwidth != 0 {
+		api.ResponseFilterExpression(ports, api.EqualFilter("totalBandwidth", strconv.FormatInt(filters.TotalBandwidth, 10)))
+	}
+	return ports, nil
+}
+
+// GetPortByUUID calls GetPortByUUIDWithContext with a background context
+func (ec *ECXPortsAPI) GetPortByUUID(uuid string) (*models.UserPortResObj, error) {
+	return ec.GetPortByUUIDWithContext(context.Background(), uuid)
+}
+
+// GetPortByUUIDWithContext returns the port with uuid, ErrPortNotFound if there is none
+func (ec *ECXPortsAPI) GetPortByUUIDWithContext(ctx context.Context, uuid string) (*models.UserPortResObj, error) {
+	return ec.findPort(ctx, func(port *models.UserPortResObj) bool { return port.UUID == uuid }, "uuid "+uuid)
+}
 
+// GetPortByName calls GetPortByNameWithContext with a background context
+func (ec *ECXPortsAPI) GetPortByName(name string) (*models.UserPortResObj, error) {
+	return ec.GetPortByNameWithContext(context.Background(), name)
+}
+
+// GetPortByNameWithContext returns the port named name, ErrPortNotFound if there is none
+func (ec *ECXPortsAPI) GetPortByNameWithContext(ctx context.Context, name string) (*models.UserPortResObj, error) {
+	return ec.findPort(ctx, func(port *models.UserPortResObj) bool { return port.Name == name }, "name "+name)
+}
+
+// findPort returns the first port matching, the ports API has no single port operation
+func (ec *ECXPortsAPI) findPort(ctx context.Context, match func(*models.UserPortResObj) bool, desc string) (*models.UserPortResObj, error) {
+	ports, err := ec.GetAllPortsWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, port := range ports.Items {
+		if port != nil && match(port) {
+			return port, nil
+		}
+	}
+	return nil, fmt.Errorf("%w with %s", ErrPortNotFound, desc)
 }
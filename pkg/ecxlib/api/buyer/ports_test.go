@@ -0,0 +1,84 @@
+package buyer
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jxoir/go-ecxfabric/buyer/models"
+)
+
+// newPortsTestAPI returns an ECXPortsAPI against a mocked ECX with five ports
+func newPortsTestAPI() (*ECXPortsAPI, *httptest.Server) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ecx/v3/port/userport", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `[
+			{"uuid":"port-1","name":"LD-PRI","metroCode":"LD","provisionStatus":"PROVISIONED","totalBandwidth":10000000000},
+			{"uuid":"port-2","name":"LD-SEC","metroCode":"LD","provisionStatus":"PROVISIONING","totalBandwidth":10000000000},
+			{"uuid":"port-3","name":"AM-PRI","metroCode":"AM","provisionStatus":"PROVISIONED","totalBandwidth":1000000000},
+			{"uuid":"port-4","name":"LD-OLD","metroCode":"LD","provisionStatus":"DEPROVISIONED","totalBandwidth":10000000000},
+			{"uuid":"port-5","name":"LDN-PRI","metroCode":"LDN","provisionStatus":"PROVISIONED","totalBandwidth":1000000000}]`)
+	})
+	ec, server := newTestClient(mux)
+	return NewECXPortsAPI(ec), server
+}
+
+func TestGetPorts(t *testing.T) {
+	api, server := newPortsTestAPI()
+	defer server.Close()
+
+	tests := []struct {
+		filters *PortFilters
+		uuids   []string
+	}{
+		{nil, []string{"port-1", "port-2", "port-3", "port-4", "port-5"}},
+		{&PortFilters{MetroCode: "LD"}, []string{"port-1", "port-2", "port-4"}},
+		{&PortFilters{MetroCode: "LD", ProvisionStatus: "PROVISIONED"}, []string{"port-1"}},
+		{&PortFilters{ProvisionStatus: "DEPROVISIONED"}, []string{"port-4"}},
+		{&PortFilters{TotalBandwidth: 1000000000}, []string{"port-3", "port-5"}},
+	}
+	for _, test := range tests {
+		ports, err := api.GetPorts(test.filters)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var uuids []string
+		for _, port := range ports.Items {
+			uuids = append(uuids, port.UUID)
+		}
+		if fmt.Sprint(uuids) != fmt.Sprint(test.uuids) {
+			t.Errorf("Filters %+v: expected %v, received %v", test.filters, test.uuids, uuids)
+		}
+	}
+}
+
+func TestGetPortByUUIDAndName(t *testing.T) {
+	api, server := newPortsTestAPI()
+	defer server.Close()
+
+	if port, err := api.GetPortByUUID("port-2"); err != nil || port.Name != "LD-SEC" {
+		t.Errorf("Expected port LD-SEC, received %+v %v", port, err)
+	}
+	if port, err := api.GetPortByName("AM-PRI"); err != nil || port.UUID != "port-3" {
+		t.Errorf("Expected port-3, received %+v %v", port, err)
+	}
+	if _, err := api.GetPortByUUID("port-6"); !errors.Is(err, ErrPortNotFound) {
+		t.Errorf("Expected ErrPortNotFound, received %v", err)
+	}
+}
+
+func TestPortConnections(t *testing.T) {
+	conns := []*models.GetBuyerConResContent{
+		{UUID: "uuid-1", PortUUID: "port-1"},
+		{UUID: "uuid-2", PortUUID: "port-2"},
+		{UUID: "uuid-3", PortUUID: "port-2", ZSidePortUUID: "port-1"},
+	}
+
+	portConns := PortConnections("port-1", conns)
+	if portConns.Count() != 2 || portConns.Items[0].UUID != "uuid-1" || portConns.Items[1].UUID != "uuid-3" {
+		t.Errorf("Expected uuid-1 and uuid-3, received %+v", portConns.Items)
+	}
+}
@@ -1,24 +1,145 @@
 package buyer
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
+	"net/http"
+	"strings"
+	"time"
 
+	"github.com/go-openapi/runtime"
+	"github.com/go-openapi/strfmt"
 	api "github.com/jxoir/equinix-tools/pkg/ecxlib/api/client"
 	apiroutinginstance "github.com/jxoir/go-ecxfabric/buyer/client/routing_instance"
 	apiroutinginstancemodel "github.com/jxoir/go-ecxfabric/buyer/models"
 )
 
 type RoutingInstanceAPIHandler interface {
-	GetAllRoutingInstances() (*apiroutinginstance.GetAllRoutingInstancesUsingGETOK, error)
+	GetAllRoutingInstances(params *GetAllRoutingInstancesParams) (*RoutingInstancesResponse, error)
+	GetAllRoutingInstancesWithContext(ctx context.Context, params *GetAllRoutingInstancesParams) (*RoutingInstancesResponse, error)
+	GetRoutingInstances(params *GetAllRoutingInstancesParams) (*RoutingInstancesResponse, error)
+	GetRoutingInstancesWithContext(ctx context.Context, params *GetAllRoutingInstancesParams) (*RoutingInstancesResponse, error)
+	GetRoutingInstance(uuid string) (*apiroutinginstancemodel.RoutingInstancev3, error)
+	GetRoutingInstanceWithContext(ctx context.Context, uuid string) (*apiroutinginstancemodel.RoutingInstancev3, error)
+	UpdateRoutingInstance(uuid string, params *UpdateRoutingInstanceParams) error
+	UpdateRoutingInstanceWithContext(ctx context.Context, uuid string, params *UpdateRoutingInstanceParams) error
+	DeleteRoutingInstance(uuid string) error
+	DeleteRoutingInstanceWithContext(ctx context.Context, uuid string) error
 }
 
+// DefaultRoutingInstancesPageSize page size used by GetAllRoutingInstances when none is requested
+const DefaultRoutingInstancesPageSize int32 = 100
+
 type ECXRoutingInstanceAPI struct {
 	*api.EquinixAPIClient
 }
 
+// Route types of a routing instance
+const (
+	RouteTypePrivate = "Private"
+	RouteTypePublic  = "Public"
+)
+
+// Largest 16-bit and 32-bit ASN
+const (
+	MaxASN16 int64 = 65535
+	MaxASN32 int64 = 4294967295
+)
+
+// Length of a BGP authorization key
+const (
+	MinBgpAuthorizationKeyLength = 6
+	MaxBgpAuthorizationKeyLength = 32
+)
+
+// RoutingInstanceStateProvisioned state of a routing instance ready to use
+const RoutingInstanceStateProvisioned = "PROVISIONED"
+
+// RoutingInstanceFailedStates states stopping WaitForRoutingInstanceState with a RoutingInstanceStateError, unless
+// waited for
+var RoutingInstanceFailedStates = []string{"FAILED", "NOT_PROVISIONED", "DEPROVISIONED"}
+
+// ErrRoutingInstanceExists returned by CreateRoutingInstance when a name is already used in the metro
+var ErrRoutingInstanceExists = errors.New("routing instance name already exists")
+
+// ErrInvalidASN returned for ASNs out of range, reserved or not matching the route type
+var ErrInvalidASN = errors.New("invalid ASN")
+
+// ErrInvalidBgpAuthorizationKey returned for BGP keys of the wrong length or with unsupported characters
+var ErrInvalidBgpAuthorizationKey = errors.New("invalid BGP authorization key")
+
+// RoutingInstanceStateError routing instance reached a failed state while waiting for another one
+type RoutingInstanceStateError struct {
+	UUID  string
+	State string
+}
+
+// Error returns the routing instance and its state
+func (e *RoutingInstanceStateError) Error() string {
+	return fmt.Sprintf("routing instance %s reached state %s", e.UUID, e.State)
+}
+
+// IsPrivateASN returns true for the private use ranges of RFC 6996, 64512-65534 and 4200000000-4294967294
+func IsPrivateASN(asn int64) bool {
+	return asn >= 64512 && asn <= 65534 || asn >= 4200000000 && asn <= 4294967294
+}
+
+// isReservedASN returns true for AS_TRANS, the documentation ranges and the reserved ASNs
+func isReservedASN(asn int64) bool {
+	return asn == 0 || asn == 23456 || asn == MaxASN16 || asn == MaxASN32 ||
+		asn >= 64496 && asn <= 64511 || asn >= 65536 && asn <= 131071
+}
+
+// ValidateASN checks asn is a 16-bit or 32-bit ASN that isn't reserved, private for the Private route type and
+// public for the Public one
+func ValidateASN(asn int64, routeType string) error {
+	if asn < 1 || asn > MaxASN32 {
+		return fmt.Errorf("%w %d, must be between 1 and %d (16-bit) or %d (32-bit)", ErrInvalidASN, asn, MaxASN16, MaxASN32)
+	}
+	if isReservedASN(asn) {
+		return fmt.Errorf("%w %d, the ASN is reserved", ErrInvalidASN, asn)
+	}
+
+	switch {
+	case strings.EqualFold(routeType, RouteTypePrivate):
+		if !IsPrivateASN(asn) {
+			return fmt.Errorf("%w %d, %s route type requires a private ASN (64512-65534 or 4200000000-4294967294)", ErrInvalidASN, asn, RouteTypePrivate)
+		}
+	case strings.EqualFold(routeType, RouteTypePublic):
+		if IsPrivateASN(asn) {
+			return fmt.Errorf("%w %d, %s route type requires a public ASN", ErrInvalidASN, asn, RouteTypePublic)
+		}
+	default:
+		return fmt.Errorf("invalid route type %q, must be %s or %s", routeType, RouteTypePrivate, RouteTypePublic)
+	}
+	return nil
+}
+
+// ValidateBgpAuthorizationKey checks key length and that it's made of printable ascii characters other than spaces,
+// quotes and backslashes
+func ValidateBgpAuthorizationKey(key string) error {
+	if len(key) < MinBgpAuthorizationKeyLength || len(key) > MaxBgpAuthorizationKeyLength {
+		return fmt.Errorf("%w, must be %d to %d characters long", ErrInvalidBgpAuthorizationKey, MinBgpAuthorizationKeyLength, MaxBgpAuthorizationKeyLength)
+	}
+	for _, c := range key {
+		if c <= ' ' || c > '~' || strings.ContainsRune("'\"`\\", c) {
+			return fmt.Errorf("%w, character %q not allowed", ErrInvalidBgpAuthorizationKey, c)
+		}
+	}
+	return nil
+}
+
+// RoutingInstancesResponse wrapper for swagger RoutingInstancev3 list
+type RoutingInstancesResponse struct {
+	api.Collection[*apiroutinginstancemodel.RoutingInstancev3]
+	TotalCount int64
+	PageSize   int64
+}
+
+// GetAllRoutingInstancesParams filters and page of the routing instances, any of States matches
 type GetAllRoutingInstancesParams struct {
 	MetroCode  *string
 	PageSize   int32
@@ -26,6 +147,8 @@ type GetAllRoutingInstancesParams struct {
 	States     []string
 }
 
+// CreateRoutingInstanceParams routing instance to create, SecondaryName goes along with RequiredRedundancy and
+// BgpAuthorizationKey with BgpUseAuth
 type CreateRoutingInstanceParams struct {
 	MetroCode           string
 	PrimaryName         string
@@ -38,60 +161,242 @@ type CreateRoutingInstanceParams struct {
 	NotificationEmails  []string
 }
 
+// Validate checks the required fields, the ASN against the route type and the BGP key
+func (p *CreateRoutingInstanceParams) Validate() error {
+	if p.MetroCode == "" || p.PrimaryName == "" {
+		return errors.New("metro code and name are required")
+	}
+	if len(p.NotificationEmails) == 0 {
+		return errors.New("a routing instance requires at least one notification email")
+	}
+	if p.RequiredRedundancy {
+		if p.SecondaryName == "" {
+			return errors.New("secondary name is required with redundancy")
+		}
+		if p.SecondaryName == p.PrimaryName {
+			return errors.New("primary and secondary names must be different")
+		}
+	} else if p.SecondaryName != "" {
+		return errors.New("secondary name set without redundancy")
+	}
+	if err := ValidateASN(p.Asn, p.RouteType); err != nil {
+		return err
+	}
+	if p.BgpUseAuth {
+		return ValidateBgpAuthorizationKey(p.BgpAuthorizationKey)
+	}
+	if p.BgpAuthorizationKey != "" {
+		return errors.New("BGP authorization key set without BGP authentication")
+	}
+	return nil
+}
+
+// createRoutingInstanceRequest body of the routing instance creation, the generated RoutingInstanceCreateRequest
+// drops the redundancy and BGP authentication flags
+type createRoutingInstanceRequest struct {
+	MetroCode           string   `json:"metroCode"`
+	PrimaryRIName       string   `json:"primaryRIName"`
+	SecondaryRIName     string   `json:"secondaryRIName,omitempty"`
+	RequiredRedundancy  bool     `json:"requiredRedundancy"`
+	RouteType           string   `json:"routeType"`
+	Asn                 int64    `json:"asn"`
+	BgpUseAuth          bool     `json:"bgpUseAuth"`
+	BgpAuthorizationKey string   `json:"bgpAuthorizationKey,omitempty"`
+	NotificationEmails  []string `json:"notificationEmails"`
+}
+
+// createRoutingInstanceParams writes createRoutingInstanceRequest into a swagger request
+type createRoutingInstanceParams struct {
+	Request *createRoutingInstanceRequest
+}
+
+// WriteToRequest writes these params to a swagger request
+func (o *createRoutingInstanceParams) WriteToRequest(r runtime.ClientRequest, reg strfmt.Registry) error {
+	return r.SetBodyParam(o.Request)
+}
+
+// UpdateRoutingInstanceParams changes applied by UpdateRoutingInstance, empty fields keep the current value
+type UpdateRoutingInstanceParams struct {
+	Name string
+
+	// NotificationEmails replaces the notification emails, AddNotificationEmails and RemoveNotificationEmails edit
+	// the current ones
+	NotificationEmails       []string
+	AddNotificationEmails    []string
+	RemoveNotificationEmails []string
+
+	// BgpAuthorizationKey new BGP MD5 key
+	BgpAuthorizationKey string
+}
+
+// updateRoutingInstanceRequest body of the routing instance update, the generated RoutingInstanceUpdateRequest
+// can't rotate the BGP key
+type updateRoutingInstanceRequest struct {
+	Name                string   `json:"name,omitempty"`
+	NotificationEmails  []string `json:"notificationEmails"`
+	BgpAuthorizationKey string   `json:"bgpAuthorizationKey,omitempty"`
+}
+
+// routingInstanceParams writes the uuid path param, along with the update body and lastUpdatedDate header if set
+type routingInstanceParams struct {
+	UUID            string
+	LastUpdatedDate string
+	Request         *updateRoutingInstanceRequest
+}
+
+// WriteToRequest writes these params to a swagger request
+func (o *routingInstanceParams) WriteToRequest(r runtime.ClientRequest, reg strfmt.Registry) error {
+	if err := r.SetPathParam("uuid", o.UUID); err != nil {
+		return err
+	}
+	if o.LastUpdatedDate != "" {
+		if err := r.SetHeaderParam("lastUpdatedDate", o.LastUpdatedDate); err != nil {
+			return err
+		}
+	}
+	if o.Request != nil {
+		return r.SetBodyParam(o.Request)
+	}
+	return nil
+}
+
+// getRoutingInstanceReader reads the routing instance returned by ECX, the generated client has no get by uuid
+type getRoutingInstanceReader struct{}
+
+// ReadResponse reads a server response into the received o
+func (o *getRoutingInstanceReader) ReadResponse(response runtime.ClientResponse, consumer runtime.Consumer) (interface{}, error) {
+	if response.Code() == http.StatusOK {
+		result := &apiroutinginstancemodel.RoutingInstancev3{}
+		if err := consumer.Consume(response.Body(), result); err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+
+	result := &getRoutingInstanceError{Code: response.Code()}
+	// error bodies are optional, the status code is enough to build the error
+	consumer.Consume(response.Body(), &result.Payload)
+	return nil, result
+}
+
+// getRoutingInstanceError error response of the get routing instance operation, Payload is parsed by APIError
+type getRoutingInstanceError struct {
+	Code    int
+	Payload interface{}
+}
+
+func (o *getRoutingInstanceError) Error() string {
+	return fmt.Sprintf("[GET /ecx/v3/l3/routinginstance/{uuid}][%d] getRoutingInstanceUsingGET  %+v", o.Code, o.Payload)
+}
+
 // NewECXRoutingInstanceAPI returns instantiated ECXMetrosAPI struct
 func NewECXRoutingInstanceAPI(equinixAPIClient *api.EquinixAPIClient) *ECXRoutingInstanceAPI {
 	return &ECXRoutingInstanceAPI{equinixAPIClient}
 }
 
-// CreateRoutingInstance returns routing instance primary uuid or error
+// CreateRoutingInstance calls CreateRoutingInstanceWithContext with a background context
 func (ec *ECXRoutingInstanceAPI) CreateRoutingInstance(params *CreateRoutingInstanceParams) (string, error) {
-	token, err := ec.GetToken()
+	return ec.CreateRoutingInstanceWithContext(context.Background(), params)
+}
+
+// CreateRoutingInstanceWithContext creates the routing instances as CreateRoutingInstancesWithContext does and
+// returns the primary uuid
+func (ec *ECXRoutingInstanceAPI) CreateRoutingInstanceWithContext(ctx context.Context, params *CreateRoutingInstanceParams) (string, error) {
+	res, err := ec.CreateRoutingInstancesWithContext(ctx, params)
 	if err != nil {
-		log.Fatal(err)
+		return "", err
 	}
+	return res.PrimaryRIUUID, nil
+}
 
-	apiParams := apiroutinginstance.NewCreateRoutingInstanceUsingPOSTParams()
-	routingRequest := &apiroutinginstancemodel.RoutingInstanceCreateRequest{}
-	apiParams.Request = routingRequest
+// CreateRoutingInstances calls CreateRoutingInstancesWithContext with a background context
+func (ec *ECXRoutingInstanceAPI) CreateRoutingInstances(params *CreateRoutingInstanceParams) (*apiroutinginstancemodel.RoutingInstanceCreateResponse, error) {
+	return ec.CreateRoutingInstancesWithContext(context.Background(), params)
+}
 
-	apiParams.Request.Asn = params.Asn
-	apiParams.Request.BgpAuthorizationKey = params.BgpAuthorizationKey
-	apiParams.Request.MetroCode = params.MetroCode
-	apiParams.Request.PrimaryRIName = params.PrimaryName
-	apiParams.Request.SecondaryRIName = params.SecondaryName
-	apiParams.Request.NotificationEmails = params.NotificationEmails
-	apiParams.Request.RouteType = params.RouteType
+// CreateRoutingInstancesWithContext validates params and creates the routing instance, along with its secondary when
+// redundancy is required, returning both uuids. Returns ErrRoutingInstanceExists if any of the names is taken in the
+// metro.
+func (ec *ECXRoutingInstanceAPI) CreateRoutingInstancesWithContext(ctx context.Context, params *CreateRoutingInstanceParams) (*apiroutinginstancemodel.RoutingInstanceCreateResponse, error) {
+	if params == nil {
+		return nil, errors.New("Parameters to create routing instance not provided")
+	}
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
 
-	routingInstanceExists, err := ec.CheckRoutingInstanceNameExists(params.PrimaryName, params.MetroCode)
-	if err != nil {
-		return "", err
+	request := &createRoutingInstanceRequest{
+		MetroCode:          params.MetroCode,
+		PrimaryRIName:      params.PrimaryName,
+		RequiredRedundancy: params.RequiredRedundancy,
+		RouteType:          params.RouteType,
+		Asn:                params.Asn,
+		BgpUseAuth:         params.BgpUseAuth,
+		NotificationEmails: params.NotificationEmails,
+	}
+	names := []string{params.PrimaryName}
+	if params.RequiredRedundancy {
+		request.SecondaryRIName = params.SecondaryName
+		names = append(names, params.SecondaryName)
+	}
+	if params.BgpUseAuth {
+		request.BgpAuthorizationKey = params.BgpAuthorizationKey
 	}
 
-	if routingInstanceExists {
-		log.Fatal("Routing instance name already exists, please choose another name")
+	for _, name := range names {
+		routingInstanceExists, err := ec.CheckRoutingInstanceNameExistsWithContext(ctx, name, params.MetroCode)
+		if err != nil {
+			return nil, err
+		}
+		if routingInstanceExists {
+			return nil, fmt.Errorf("%w: %s in metro %s", ErrRoutingInstanceExists, name, params.MetroCode)
+		}
 	}
 
-	routingInstanceOk, routingInstanceNC, err := ec.Buyer.RoutingInstance.CreateRoutingInstanceUsingPOST(apiParams, token)
+	token, err := ec.GetTokenWithContext(ctx)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+
+	result, err := ec.Submit(&runtime.ClientOperation{
+		ID:                 "createRoutingInstanceUsingPOST",
+		Method:             "POST",
+		PathPattern:        "/ecx/v3/l3/routinginstance",
+		ProducesMediaTypes: []string{"application/json"},
+		ConsumesMediaTypes: []string{"application/json"},
+		Schemes:            []string{"https"},
+		Params:             &createRoutingInstanceParams{Request: request},
+		Reader:             &apiroutinginstance.CreateRoutingInstanceUsingPOSTReader{},
+		AuthInfo:           token,
+		Context:            ctx,
+	})
+	if err != nil {
+		return nil, err
 	}
-	if routingInstanceNC != nil {
-		return "", errors.New("No content")
+
+	routingInstanceOk, ok := result.(*apiroutinginstance.CreateRoutingInstanceUsingPOSTCreated)
+	if !ok || routingInstanceOk.Payload == nil {
+		return nil, errors.New("No content")
 	}
 
-	return routingInstanceOk.Payload.PrimaryRIUUID, nil
+	return routingInstanceOk.Payload, nil
 
 }
 
-// CheckRoutingInstanceNameExists returns bool or error
+// CheckRoutingInstanceNameExists calls CheckRoutingInstanceNameExistsWithContext with a background context
 func (ec *ECXRoutingInstanceAPI) CheckRoutingInstanceNameExists(name string, metroCode string) (bool, error) {
+	return ec.CheckRoutingInstanceNameExistsWithContext(context.Background(), name, metroCode)
+}
+
+// CheckRoutingInstanceNameExistsWithContext returns bool or error
+func (ec *ECXRoutingInstanceAPI) CheckRoutingInstanceNameExistsWithContext(ctx context.Context, name string, metroCode string) (bool, error) {
 
-	token, err := ec.GetToken()
+	token, err := ec.GetTokenWithContext(ctx)
 	if err != nil {
-		log.Fatal(err)
+		return false, err
 	}
 
-	apiParams := apiroutinginstance.NewIsRoutingInstanceExistUsingGETParams()
+	apiParams := apiroutinginstance.NewIsRoutingInstanceExistUsingGETParamsWithContext(ctx)
 
 	apiParams.MetroCode = metroCode
 	apiParams.Name = name
@@ -113,8 +418,56 @@ func (ec *ECXRoutingInstanceAPI) CheckRoutingInstanceNameExists(name string, met
 	return false, nil
 }
 
-// GetAllRoutingInstances returns array of GetAllRoutingInstancesUsingGETOK with list of routing instances
-func (ec *ECXRoutingInstanceAPI) GetAllRoutingInstances(params *GetAllRoutingInstancesParams) (*apiroutinginstance.GetAllRoutingInstancesUsingGETOK, error) {
+// GetAllRoutingInstances calls GetAllRoutingInstancesWithContext with a background context
+func (ec *ECXRoutingInstanceAPI) GetAllRoutingInstances(params *GetAllRoutingInstancesParams) (*RoutingInstancesResponse, error) {
+	return ec.GetAllRoutingInstancesWithContext(context.Background(), params)
+}
+
+// GetAllRoutingInstancesWithContext get all routing instances (traversing pagination, params.PageNumber is ignored),
+// on error returns the routing instances fetched so far along with the error
+func (ec *ECXRoutingInstanceAPI) GetAllRoutingInstancesWithContext(ctx context.Context, params *GetAllRoutingInstancesParams) (*RoutingInstancesResponse, error) {
+	pageParams := GetAllRoutingInstancesParams{PageSize: DefaultRoutingInstancesPageSize}
+	if params != nil {
+		pageParams.MetroCode = params.MetroCode
+		pageParams.States = params.States
+		if params.PageSize > 0 {
+			pageParams.PageSize = params.PageSize
+		}
+	}
+	pageParams.PageNumber = 1
+
+	routingInstancesList, err := ec.GetRoutingInstancesWithContext(ctx, &pageParams)
+	if err != nil {
+		return nil, err
+	}
+
+	pageSize := routingInstancesList.PageSize
+	if pageSize == 0 {
+		pageSize = int64(pageParams.PageSize)
+	}
+
+	// WalkPages numbers pages from 0 while routing instance pages start at 1
+	err = ec.WalkPages(ctx, routingInstancesList.TotalCount, pageSize,
+		func(ctx context.Context, pageNumber int32, pageSize int32) (interface{}, error) {
+			page := pageParams
+			page.PageNumber = pageNumber + 1
+			page.PageSize = pageSize
+			return ec.GetRoutingInstancesWithContext(ctx, &page)
+		},
+		func(page interface{}) {
+			routingInstancesList.Items = append(routingInstancesList.Items, page.(*RoutingInstancesResponse).Items...)
+		})
+
+	return routingInstancesList, err
+}
+
+// GetRoutingInstances calls GetRoutingInstancesWithContext with a background context
+func (ec *ECXRoutingInstanceAPI) GetRoutingInstances(params *GetAllRoutingInstancesParams) (*RoutingInstancesResponse, error) {
+	return ec.GetRoutingInstancesWithContext(context.Background(), params)
+}
+
+// GetRoutingInstancesWithContext returns the requested page of routing instances
+func (ec *ECXRoutingInstanceAPI) GetRoutingInstancesWithContext(ctx context.Context, params *GetAllRoutingInstancesParams) (*RoutingInstancesResponse, error) {
 	if params == nil {
 		params = &GetAllRoutingInstancesParams{
 			PageNumber: 1,
@@ -122,12 +475,12 @@ func (ec *ECXRoutingInstanceAPI) GetAllRoutingInstances(params *GetAllRoutingIns
 		}
 	}
 
-	token, err := ec.GetToken()
+	token, err := ec.GetTokenWithContext(ctx)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
-	apiParams := apiroutinginstance.NewGetAllRoutingInstancesUsingGETParams()
+	apiParams := apiroutinginstance.NewGetAllRoutingInstancesUsingGETParamsWithContext(ctx)
 
 	apiParams.MetroCode = params.MetroCode
 	apiParams.PageNumber = params.PageNumber
@@ -149,19 +502,181 @@ func (ec *ECXRoutingInstanceAPI) GetAllRoutingInstances(params *GetAllRoutingIns
 				log.Println(t.Field)
 				log.Println(t.Offset)
 			}
-		case *apiroutinginstance.GetAllRoutingInstancesUsingGETBadRequest:
-			if ec.Debug {
-				log.Println("Bad request")
-			}
-		case *apiroutinginstance.GetAllRoutingInstancesUsingGETNoContent:
-			if ec.Debug {
-				fmt.Println(t.Error())
-			}
 		}
 		return nil, err
 
 	}
 
-	return respRoutingInstancesOk, nil
+	routingInstancesList := &RoutingInstancesResponse{}
+	if respRoutingInstancesOk != nil && respRoutingInstancesOk.Payload != nil {
+		routingInstancesList.Items = respRoutingInstancesOk.Payload.RoutingInstances
+		routingInstancesList.TotalCount = respRoutingInstancesOk.Payload.TotalCount
+		routingInstancesList.PageSize = respRoutingInstancesOk.Payload.PageSize
+	}
+
+	return routingInstancesList, nil
 
 }
+
+// GetRoutingInstance calls GetRoutingInstanceWithContext with a background context
+func (ec *ECXRoutingInstanceAPI) GetRoutingInstance(uuid string) (*apiroutinginstancemodel.RoutingInstancev3, error) {
+	return ec.GetRoutingInstanceWithContext(context.Background(), uuid)
+}
+
+// GetRoutingInstanceWithContext get routing instance by uuid
+func (ec *ECXRoutingInstanceAPI) GetRoutingInstanceWithContext(ctx context.Context, uuid string) (*apiroutinginstancemodel.RoutingInstancev3, error) {
+	token, err := ec.GetTokenWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := ec.Submit(&runtime.ClientOperation{
+		ID:                 "getRoutingInstanceUsingGET",
+		Method:             "GET",
+		PathPattern:        "/ecx/v3/l3/routinginstance/{uuid}",
+		ProducesMediaTypes: []string{"application/json"},
+		ConsumesMediaTypes: []string{"application/json"},
+		Schemes:            []string{"https"},
+		Params:             &routingInstanceParams{UUID: uuid},
+		Reader:             &getRoutingInstanceReader{},
+		AuthInfo:           token,
+		Context:            ctx,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*apiroutinginstancemodel.RoutingInstancev3), nil
+}
+
+// UpdateRoutingInstance calls UpdateRoutingInstanceWithContext with a background context
+func (ec *ECXRoutingInstanceAPI) UpdateRoutingInstance(uuid string, params *UpdateRoutingInstanceParams) error {
+	return ec.UpdateRoutingInstanceWithContext(context.Background(), uuid, params)
+}
+
+// UpdateRoutingInstanceWithContext renames the routing instance, changes its notification emails or rotates its BGP
+// key. The current instance is fetched first, ErrNothingToUpdate is returned if params don't change it.
+func (ec *ECXRoutingInstanceAPI) UpdateRoutingInstanceWithContext(ctx context.Context, uuid string, params *UpdateRoutingInstanceParams) error {
+	if params == nil {
+		return errors.New("Parameters to update routing instance not provided")
+	}
+
+	current, err := ec.GetRoutingInstanceWithContext(ctx, uuid)
+	if err != nil {
+		return err
+	}
+
+	request := &updateRoutingInstanceRequest{Name: current.Name, NotificationEmails: current.NotificationEmails}
+	changed := false
+
+	if params.Name != "" && params.Name != current.Name {
+		request.Name = params.Name
+		changed = true
+	}
+
+	if params.NotificationEmails != nil || len(params.AddNotificationEmails) > 0 || len(params.RemoveNotificationEmails) > 0 {
+		emails := editNotifications(current.NotificationEmails, params.NotificationEmails, params.AddNotificationEmails, params.RemoveNotificationEmails)
+		if len(emails) == 0 {
+			return errors.New("a routing instance requires at least one notification email")
+		}
+		if !equalStrings(emails, current.NotificationEmails) {
+			request.NotificationEmails = emails
+			changed = true
+		}
+	}
+
+	if params.BgpAuthorizationKey != "" {
+		if err := ValidateBgpAuthorizationKey(params.BgpAuthorizationKey); err != nil {
+			return err
+		}
+		request.BgpAuthorizationKey = params.BgpAuthorizationKey
+		changed = true
+	}
+
+	if !changed {
+		return ErrNothingToUpdate
+	}
+
+	token, err := ec.GetTokenWithContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = ec.Submit(&runtime.ClientOperation{
+		ID:                 "updateRoutingInstanceUsingPATCH",
+		Method:             "PATCH",
+		PathPattern:        "/ecx/v3/l3/routinginstance/{uuid}",
+		ProducesMediaTypes: []string{"application/json"},
+		ConsumesMediaTypes: []string{"application/json"},
+		Schemes:            []string{"https"},
+		Params:             &routingInstanceParams{UUID: uuid, LastUpdatedDate: current.LastUpdatedDate, Request: request},
+		Reader:             &apiroutinginstance.UpdateRoutingInstanceUsingPATCHReader{},
+		AuthInfo:           token,
+		Context:            ctx,
+	})
+	return err
+}
+
+// DeleteRoutingInstance calls DeleteRoutingInstanceWithContext with a background context
+func (ec *ECXRoutingInstanceAPI) DeleteRoutingInstance(uuid string) error {
+	return ec.DeleteRoutingInstanceWithContext(context.Background(), uuid)
+}
+
+// DeleteRoutingInstanceWithContext deletes routing instance by uuid
+func (ec *ECXRoutingInstanceAPI) DeleteRoutingInstanceWithContext(ctx context.Context, uuid string) error {
+	token, err := ec.GetTokenWithContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	params := apiroutinginstance.NewDeleteRoutingInstanceUsingDELETEParamsWithContext(ctx)
+	params.UUID = uuid
+
+	_, err = ec.Buyer.RoutingInstance.DeleteRoutingInstanceUsingDELETE(params, token)
+	return err
+}
+
+// WaitForRoutingInstanceState calls WaitForRoutingInstanceStateWithContext with a background context
+func (ec *ECXRoutingInstanceAPI) WaitForRoutingInstanceState(uuid string, states []string, timeout time.Duration) (*apiroutinginstancemodel.RoutingInstancev3, error) {
+	return ec.WaitForRoutingInstanceStateWithContext(context.Background(), uuid, states, timeout)
+}
+
+// WaitForRoutingInstanceStateWithContext polls the routing instance until its state is one of states and returns it.
+// Polls are spaced by the client PollPolicy. Returns a RoutingInstanceStateError if a failed state is reached and
+// ErrWaitTimeout when timeout (if > 0) expires.
+func (ec *ECXRoutingInstanceAPI) WaitForRoutingInstanceStateWithContext(ctx context.Context, uuid string, states []string, timeout time.Duration) (*apiroutinginstancemodel.RoutingInstancev3, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	var instance *apiroutinginstancemodel.RoutingInstancev3
+	lastState := ""
+	err := ec.Poll(ctx, func(ctx context.Context) (bool, error) {
+		var err error
+		instance, err = ec.GetRoutingInstanceWithContext(ctx, uuid)
+		if err != nil {
+			return false, err
+		}
+		lastState = instance.State
+		if ec.Debug {
+			log.Printf("Routing instance %s state %s\n", uuid, lastState)
+		}
+		if containsStatus(states, lastState) {
+			return true, nil
+		}
+		if containsStatus(RoutingInstanceFailedStates, lastState) {
+			return true, &RoutingInstanceStateError{UUID: uuid, State: lastState}
+		}
+		return false, nil
+	})
+
+	var stateErr *RoutingInstanceStateError
+	switch {
+	case err == nil, errors.As(err, &stateErr):
+		return instance, err
+	case err == context.DeadlineExceeded:
+		return nil, fmt.Errorf("%w: routing instance %s last state %q after %s", ErrWaitTimeout, uuid, lastState, timeout)
+	}
+	return nil, err
+}
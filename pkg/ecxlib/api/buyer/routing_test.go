@@ -0,0 +1,267 @@
+package buyer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jxoir/equinix-tools/pkg/ecxlib/api/client"
+)
+
+func TestGetAllRoutingInstances(t *testing.T) {
+	var mu sync.Mutex
+	var states [][]string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ecx/v3/l3/routinginstance", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		states = append(states, r.URL.Query()["states"])
+		mu.Unlock()
+
+		page, _ := strconv.Atoi(r.URL.Query().Get("pageNumber"))
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"totalCount":5,"pageSize":2,"pageNumber":%d,"routingInstances":[`, page)
+		for i := (page-1)*2 + 1; i <= page*2 && i <= 5; i++ {
+			if i > (page-1)*2+1 {
+				fmt.Fprint(w, ",")
+			}
+			fmt.Fprintf(w, `{"uuid":"ri-%d","state":"PROVISIONED"}`, i)
+		}
+		fmt.Fprint(w, `]}`)
+	})
+	ec, server := newTestClient(mux)
+	defer server.Close()
+	api := NewECXRoutingInstanceAPI(ec)
+
+	list, err := api.GetAllRoutingInstances(&GetAllRoutingInstancesParams{PageSize: 2, States: []string{"PROVISIONED", "PROVISIONING"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var uuids []string
+	for _, instance := range list.Items {
+		uuids = append(uuids, instance.UUID)
+	}
+	if !reflect.DeepEqual(uuids, []string{"ri-1", "ri-2", "ri-3", "ri-4", "ri-5"}) {
+		t.Errorf("Expected 5 routing instances in order, received %v", uuids)
+	}
+	if len(states) != 3 || !reflect.DeepEqual(states[0], []string{"PROVISIONED", "PROVISIONING"}) {
+		t.Errorf("Expected 3 requests with both states, received %v", states)
+	}
+}
+
+// newRoutingInstanceTestAPI returns an ECXRoutingInstanceAPI against a mocked ECX with routing instance ri-1, updates
+// are decoded into patched and deletes counted in deleted
+func newRoutingInstanceTestAPI(patched *map[string]interface{}, lastUpdated *string, deleted *int) (*ECXRoutingInstanceAPI, *httptest.Server) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ecx/v3/l3/routinginstance/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path != "/ecx/v3/l3/routinginstance/ri-1" {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"code":"IC-RI-404","message":"Routing instance not found"}`)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			fmt.Fprint(w, `{"uuid":"ri-1","name":"RI-PRI","state":"PROVISIONED","lastUpdatedDate":"2024-05-01T10:00:00.000Z",
+				"notificationEmails":["noc@example.com"]}`)
+		case http.MethodPatch:
+			*lastUpdated = r.Header.Get("lastUpdatedDate")
+			json.NewDecoder(r.Body).Decode(patched)
+			w.WriteHeader(http.StatusNoContent)
+		case http.MethodDelete:
+			*deleted++
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+	ec, server := newTestClient(mux)
+	return NewECXRoutingInstanceAPI(ec), server
+}
+
+func TestGetRoutingInstance(t *testing.T) {
+	api, server := newRoutingInstanceTestAPI(nil, nil, nil)
+	defer server.Close()
+
+	instance, err := api.GetRoutingInstance("ri-1")
+	if err != nil || instance.Name != "RI-PRI" || instance.State != "PROVISIONED" {
+		t.Errorf("Expected RI-PRI, received %+v %v", instance, err)
+	}
+
+	if _, err := api.GetRoutingInstance("ri-2"); !client.IsStatus(err, http.StatusNotFound) {
+		t.Errorf("Expected 404 error, received %v", err)
+	}
+}
+
+func TestUpdateRoutingInstance(t *testing.T) {
+	var patched map[string]interface{}
+	var lastUpdated string
+	api, server := newRoutingInstanceTestAPI(&patched, &lastUpdated, nil)
+	defer server.Close()
+
+	err := api.UpdateRoutingInstance("ri-1", &UpdateRoutingInstanceParams{
+		AddNotificationEmails: []string{"ops@example.com"},
+		BgpAuthorizationKey:   "new-key",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if patched["name"] != "RI-PRI" || patched["bgpAuthorizationKey"] != "new-key" ||
+		fmt.Sprint(patched["notificationEmails"]) != "[noc@example.com ops@example.com]" {
+		t.Errorf("Unexpected update request %v", patched)
+	}
+	if lastUpdated != "2024-05-01T10:00:00.000Z" {
+		t.Errorf("Expected lastUpdatedDate header of the current instance, received %q", lastUpdated)
+	}
+
+	err = api.UpdateRoutingInstance("ri-1", &UpdateRoutingInstanceParams{Name: "RI-PRI", NotificationEmails: []string{"noc@example.com"}})
+	if !errors.Is(err, ErrNothingToUpdate) {
+		t.Errorf("Expected ErrNothingToUpdate, received %v", err)
+	}
+
+	// missing params are rejected before fetching the instance
+	if err := api.UpdateRoutingInstance("ri-2", nil); err == nil || client.IsStatus(err, http.StatusNotFound) {
+		t.Errorf("Expected missing parameters error, received %v", err)
+	}
+}
+
+func TestDeleteRoutingInstance(t *testing.T) {
+	var deleted int
+	api, server := newRoutingInstanceTestAPI(nil, nil, &deleted)
+	defer server.Close()
+
+	if err := api.DeleteRoutingInstance("ri-1"); err != nil || deleted != 1 {
+		t.Errorf("Expected routing instance deleted, received %d deletes %v", deleted, err)
+	}
+}
+
+func TestValidateASN(t *testing.T) {
+	tests := []struct {
+		asn       int64
+		routeType string
+		valid     bool
+	}{
+		{65000, RouteTypePrivate, true},
+		{4200000001, "private", true},
+		{3356, RouteTypePublic, true},
+		{394000, RouteTypePublic, true},
+		{3356, RouteTypePrivate, false},
+		{65000, RouteTypePublic, false},
+		{0, RouteTypePublic, false},
+		{23456, RouteTypePublic, false},
+		{64500, RouteTypePublic, false},
+		{MaxASN32 + 1, RouteTypePublic, false},
+		{65000, "Hybrid", false},
+	}
+	for _, test := range tests {
+		err := ValidateASN(test.asn, test.routeType)
+		if (err == nil) != test.valid {
+			t.Errorf("ASN %d %s: expected valid %v, received %v", test.asn, test.routeType, test.valid, err)
+		}
+	}
+}
+
+func TestValidateBgpAuthorizationKey(t *testing.T) {
+	for key, valid := range map[string]bool{
+		"s3cr3t!Key":                         true,
+		"short":                              false,
+		"with space":                         false,
+		`quote"key`:                          false,
+		"ñandú-key":                          false,
+		"0123456789012345678901234567890123": false,
+	} {
+		err := ValidateBgpAuthorizationKey(key)
+		if (err == nil) != valid {
+			t.Errorf("Key %q: expected valid %v, received %v", key, valid, err)
+		}
+		if err != nil && !errors.Is(err, ErrInvalidBgpAuthorizationKey) {
+			t.Errorf("Key %q: expected ErrInvalidBgpAuthorizationKey, received %v", key, err)
+		}
+	}
+}
+
+func TestCreateRoutingInstance(t *testing.T) {
+	var created map[string]interface{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ecx/v3/l3/routinginstance/exist/LD/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"exist":%v}`, r.URL.Path == "/ecx/v3/l3/routinginstance/exist/LD/TAKEN")
+	})
+	mux.HandleFunc("/ecx/v3/l3/routinginstance", func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&created)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"primaryRIUuid":"ri-1","secondaryRIUuid":"ri-2"}`)
+	})
+	ec, server := newTestClient(mux)
+	defer server.Close()
+	api := NewECXRoutingInstanceAPI(ec)
+
+	params := &CreateRoutingInstanceParams{
+		MetroCode:           "LD",
+		PrimaryName:         "RI-PRI",
+		SecondaryName:       "RI-SEC",
+		RequiredRedundancy:  true,
+		RouteType:           RouteTypePrivate,
+		Asn:                 65000,
+		BgpUseAuth:          true,
+		BgpAuthorizationKey: "s3cr3t!Key",
+		NotificationEmails:  []string{"noc@example.com"},
+	}
+	res, err := api.CreateRoutingInstances(params)
+	if err != nil || res.PrimaryRIUUID != "ri-1" || res.SecondaryRIUUID != "ri-2" {
+		t.Fatalf("Expected ri-1 and ri-2, received %+v %v", res, err)
+	}
+	if uuid, err := api.CreateRoutingInstance(params); err != nil || uuid != "ri-1" {
+		t.Errorf("Expected ri-1, received %s %v", uuid, err)
+	}
+	if created["requiredRedundancy"] != true || created["secondaryRIName"] != "RI-SEC" ||
+		created["bgpUseAuth"] != true || created["bgpAuthorizationKey"] != "s3cr3t!Key" {
+		t.Errorf("Unexpected create request %v", created)
+	}
+
+	params.SecondaryName = "TAKEN"
+	if _, err := api.CreateRoutingInstance(params); !errors.Is(err, ErrRoutingInstanceExists) {
+		t.Errorf("Expected ErrRoutingInstanceExists, received %v", err)
+	}
+
+	params.SecondaryName, params.RequiredRedundancy = "", false
+	params.Asn = 3356
+	if _, err := api.CreateRoutingInstance(params); !errors.Is(err, ErrInvalidASN) {
+		t.Errorf("Expected ErrInvalidASN, received %v", err)
+	}
+
+	if _, err := api.CreateRoutingInstance(nil); err == nil {
+		t.Errorf("Expected missing parameters error")
+	}
+	if _, err := api.CreateRoutingInstances(nil); err == nil {
+		t.Errorf("Expected missing parameters error")
+	}
+}
+
+func TestWaitForRoutingInstanceState(t *testing.T) {
+	var calls int
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ecx/v3/l3/routinginstance/ri-1", func(w http.ResponseWriter, r *http.Request) {
+		state := "PROVISIONING"
+		if calls++; calls == 3 {
+			state = RoutingInstanceStateProvisioned
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"uuid":"ri-1","state":%q}`, state)
+	})
+	ec, server := newTestClient(mux)
+	defer server.Close()
+	ec.PollPolicy = &client.PollPolicy{Interval: time.Millisecond}
+	api := &ECXRoutingInstanceAPI{ec}
+
+	instance, err := api.WaitForRoutingInstanceState("ri-1", []string{RoutingInstanceStateProvisioned}, time.Second)
+	if err != nil || instance.State != RoutingInstanceStateProvisioned || calls != 3 {
+		t.Errorf("Expected PROVISIONED after 3 polls, received %+v after %d polls %v", instance, calls, err)
+	}
+}
@@ -1,9 +1,8 @@
 package buyer
 
 import (
+	"context"
 	"fmt"
-	"log"
-	"math"
 
 	api "github.com/jxoir/equinix-tools/pkg/ecxlib/api/client"
 	// Well the API is messed up, so we have some calls from the buyer spec and others from seller one...
@@ -25,16 +24,16 @@ type SellerProfile struct {
 	*models.GetServProfServicesRespContent
 }
 
-// SellerProfiles initial wrapper for swagger GetBuyerConResContent
+// L2SellerProfiles wrapper for swagger GetServProfServicesRespContent list
 type L2SellerProfiles struct {
-	Items      []*models.GetServProfServicesRespContent
+	api.Collection[*models.GetServProfServicesRespContent]
 	TotalCount int64
 	PageSize   int64
 }
 
-// L3SellerServices initial wrapper for swagger GetBuyerConResContent
+// L3SellerServices wrapper for swagger SellerService list
 type L3SellerServices struct {
-	Items      []*models.SellerService
+	api.Collection[*models.SellerService]
 	TotalCount int64
 	PageSize   int64
 }
@@ -44,45 +43,80 @@ func NewECXSellerServicesAPI(equinixAPIClient *api.EquinixAPIClient) *ECXSellerS
 	return &ECXSellerServicesAPI{equinixAPIClient}
 }
 
-// GetAllL2SellerProfiles list all L2 seller profiles for given metro
+// GetAllL2SellerProfiles calls GetAllL2SellerProfilesWithContext with a background context
 func (ec *ECXSellerServicesAPI) GetAllL2SellerProfiles(metroCode *[]string) (*L2SellerProfiles, error) {
+	return ec.GetAllL2SellerProfilesWithContext(context.Background(), metroCode)
+}
+
+// GetAllL2SellerProfilesWithContext list all L2 seller profiles for given metro, on error returns the profiles
+// fetched so far along with the error
+func (ec *ECXSellerServicesAPI) GetAllL2SellerProfilesWithContext(ctx context.Context, metroCode *[]string) (*L2SellerProfiles, error) {
 	// Remember that *profiles* are a L2 service profile
 
-	respSellProfileList, err := ec.GetL2SellerProfiles(metroCode, nil, nil)
+	respSellProfileList, err := ec.GetL2SellerProfilesWithContext(ctx, metroCode, nil, nil)
 
 	if err != nil {
 		return nil, err
 	}
 
-	totalCount := respSellProfileList.TotalCount
-	pageSize := respSellProfileList.PageSize
-	totalPages := int64(math.Ceil(float64(totalCount) / float64(pageSize)))
+	if respSellProfileList == nil {
+		return nil, nil
+	}
 
-	// Start iterating from page 1 as we have "page 0" (yeah...swagger implementation of first page)
-	psize := int32(pageSize)
-	for p := 1; p <= int(totalPages-1); p++ {
-		next := int32(p)
-		req, err := ec.GetL2SellerProfiles(metroCode, &next, &psize)
-		if err != nil {
-			return nil, err
-		} else {
-			respSellProfileList.Items = append(append(respSellProfileList.Items, req.Items...))
-		}
+	err = ec.WalkPages(ctx, respSellProfileList.TotalCount, respSellProfileList.PageSize,
+		func(ctx context.Context, pageNumber int32, pageSize int32) (interface{}, error) {
+			return ec.GetL2SellerProfilesWithContext(ctx, metroCode, &pageNumber, &pageSize)
+		},
+		func(page interface{}) {
+			if req := page.(*L2SellerProfiles); req != nil {
+				respSellProfileList.Items = append(respSellProfileList.Items, req.Items...)
+			}
+		})
 
-	}
+	return respSellProfileList, err
+
+}
 
-	return respSellProfileList, nil
+// L2SellerProfilesIterator iterates over L2 seller profiles fetching pages lazily
+type L2SellerProfilesIterator struct {
+	*api.PageIterator
+}
 
+// Item returns the current L2 seller profile
+func (it *L2SellerProfilesIterator) Item() *models.GetServProfServicesRespContent {
+	item, _ := it.PageIterator.Item().(*models.GetServProfServicesRespContent)
+	return item
 }
 
-// GetL2SellerProfiles retrieve list of L2 seller profiles for a given metro with specific page number and specific page size
+// IterateL2SellerProfiles returns an iterator over the L2 seller profiles for given metro, pages are fetched as the iterator advances
+func (ec *ECXSellerServicesAPI) IterateL2SellerProfiles(ctx context.Context, metroCode *[]string) *L2SellerProfilesIterator {
+	fetch := func(ctx context.Context, pageNumber *int32, pageSize *int32) (*api.IteratorPage, error) {
+		list, err := ec.GetL2SellerProfilesWithContext(ctx, metroCode, pageNumber, pageSize)
+		if err != nil || list == nil {
+			return nil, err
+		}
+		return &api.IteratorPage{
+			Items:      list.GetItems(),
+			TotalCount: list.TotalCount,
+			PageSize:   list.PageSize,
+		}, nil
+	}
+	return &L2SellerProfilesIterator{api.NewPageIterator(ctx, fetch)}
+}
+
+// GetL2SellerProfiles calls GetL2SellerProfilesWithContext with a background context
 func (ec *ECXSellerServicesAPI) GetL2SellerProfiles(metroCode *[]string, pageNumber *int32, pageSize *int32) (*L2SellerProfiles, error) {
-	token, err := ec.GetToken()
+	return ec.GetL2SellerProfilesWithContext(context.Background(), metroCode, pageNumber, pageSize)
+}
+
+// GetL2SellerProfilesWithContext retrieve list of L2 seller profiles for a given metro with specific page number and specific page size
+func (ec *ECXSellerServicesAPI) GetL2SellerProfilesWithContext(ctx context.Context, metroCode *[]string, pageNumber *int32, pageSize *int32) (*L2SellerProfiles, error) {
+	token, err := ec.GetTokenWithContext(ctx)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
-	params := api_buyer_seller_services.NewGetProfilesByMetroUsingGETParams()
+	params := api_buyer_seller_services.NewGetProfilesByMetroUsingGETParamsWithContext(ctx)
 
 	if metroCode != nil {
 		params.MetroCode = *metroCode
@@ -98,15 +132,10 @@ func (ec *ECXSellerServicesAPI) GetL2SellerProfiles(metroCode *[]string, pageNum
 
 	respSellPOk, respSellNC, err := ec.Buyer.SellerServices.GetProfilesByMetroUsingGET(params, token)
 	if err != nil {
-		switch t := err.(type) {
-		default:
-			return nil, err
-		case *api_buyer_seller_services.GetProfilesByMetroUsingGETBadRequest:
-			if ec.Debug {
-				fmt.Println(t.Error())
-			}
-			return nil, err
+		if ec.Debug {
+			fmt.Println(err.Error())
 		}
+		return nil, err
 	}
 
 	if respSellPOk == nil {
@@ -117,7 +146,7 @@ func (ec *ECXSellerServicesAPI) GetL2SellerProfiles(metroCode *[]string, pageNum
 	}
 
 	respSellerProfilesList := L2SellerProfiles{
-		Items:      respSellPOk.Payload.Content,
+		Collection: api.Collection[*models.GetServProfServicesRespContent]{Items: respSellPOk.Payload.Content},
 		TotalCount: respSellPOk.Payload.TotalCount,
 		PageSize:   respSellPOk.Payload.PageSize,
 	}
@@ -125,45 +154,80 @@ func (ec *ECXSellerServicesAPI) GetL2SellerProfiles(metroCode *[]string, pageNum
 	return &respSellerProfilesList, nil
 }
 
-// GetAllL3SellerServices list all L3 seller profiles for given metro
+// GetAllL3SellerServices calls GetAllL3SellerServicesWithContext with a background context
 func (ec *ECXSellerServicesAPI) GetAllL3SellerServices(metroCode *[]string) (*L3SellerServices, error) {
+	return ec.GetAllL3SellerServicesWithContext(context.Background(), metroCode)
+}
+
+// GetAllL3SellerServicesWithContext list all L3 seller profiles for given metro, on error returns the services
+// fetched so far along with the error
+func (ec *ECXSellerServicesAPI) GetAllL3SellerServicesWithContext(ctx context.Context, metroCode *[]string) (*L3SellerServices, error) {
 	// Remember that *profiles* are a L2 service profile
 
-	respSellProfileList, err := ec.GetL3SellerServices(metroCode, nil, nil)
+	respSellProfileList, err := ec.GetL3SellerServicesWithContext(ctx, metroCode, nil, nil)
 
 	if err != nil {
 		return nil, err
 	}
 
-	totalCount := respSellProfileList.TotalCount
-	pageSize := respSellProfileList.PageSize
-	totalPages := int64(math.Ceil(float64(totalCount) / float64(pageSize)))
+	if respSellProfileList == nil {
+		return nil, nil
+	}
+
+	err = ec.WalkPages(ctx, respSellProfileList.TotalCount, respSellProfileList.PageSize,
+		func(ctx context.Context, pageNumber int32, pageSize int32) (interface{}, error) {
+			return ec.GetL3SellerServicesWithContext(ctx, metroCode, &pageNumber, &pageSize)
+		},
+		func(page interface{}) {
+			if req := page.(*L3SellerServices); req != nil {
+				respSellProfileList.Items = append(respSellProfileList.Items, req.Items...)
+			}
+		})
 
-	// Start iterating from page 1 as we have "page 0" (yeah...swagger implementation of first page)
-	psize := int32(pageSize)
-	for p := 1; p <= int(totalPages-1); p++ {
-		next := int32(p)
-		req, err := ec.GetL3SellerServices(metroCode, &next, &psize)
-		if err != nil {
-			return nil, err
-		} else {
-			respSellProfileList.Items = append(append(respSellProfileList.Items, req.Items...))
-		}
+	return respSellProfileList, err
 
-	}
+}
 
-	return respSellProfileList, nil
+// L3SellerServicesIterator iterates over L3 seller services fetching pages lazily
+type L3SellerServicesIterator struct {
+	*api.PageIterator
+}
 
+// Item returns the current L3 seller service
+func (it *L3SellerServicesIterator) Item() *models.SellerService {
+	item, _ := it.PageIterator.Item().(*models.SellerService)
+	return item
 }
 
-// GetL3SellerServices retrieve list of L3 seller services for a given metro with specific page number and specific page size
+// IterateL3SellerServices returns an iterator over the L3 seller services for given metro, pages are fetched as the iterator advances
+func (ec *ECXSellerServicesAPI) IterateL3SellerServices(ctx context.Context, metroCode *[]string) *L3SellerServicesIterator {
+	fetch := func(ctx context.Context, pageNumber *int32, pageSize *int32) (*api.IteratorPage, error) {
+		list, err := ec.GetL3SellerServicesWithContext(ctx, metroCode, pageNumber, pageSize)
+		if err != nil || list == nil {
+			return nil, err
+		}
+		return &api.IteratorPage{
+			Items:      list.GetItems(),
+			TotalCount: list.TotalCount,
+			PageSize:   list.PageSize,
+		}, nil
+	}
+	return &L3SellerServicesIterator{api.NewPageIterator(ctx, fetch)}
+}
+
+// GetL3SellerServices calls GetL3SellerServicesWithContext with a background context
 func (ec *ECXSellerServicesAPI) GetL3SellerServices(metroCode *[]string, pageNumber *int32, pageSize *int32) (*L3SellerServices, error) {
-	token, err := ec.GetToken()
+	return ec.GetL3SellerServicesWithContext(context.Background(), metroCode, pageNumber, pageSize)
+}
+
+// GetL3SellerServicesWithContext retrieve list of L3 seller services for a given metro with specific page number and specific page size
+func (ec *ECXSellerServicesAPI) GetL3SellerServicesWithContext(ctx context.Context, metroCode *[]string, pageNumber *int32, pageSize *int32) (*L3SellerServices, error) {
+	token, err := ec.GetTokenWithContext(ctx)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
-	params := api_buyer_seller_services.NewGetSellerServicesUsingGETParams()
+	params := api_buyer_seller_services.NewGetSellerServicesUsingGETParamsWithContext(ctx)
 
 	if metroCode != nil {
 		params.Metros = *metroCode
@@ -179,19 +243,14 @@ func (ec *ECXSellerServicesAPI) GetL3SellerServices(metroCode *[]string, pageNum
 
 	respSellPOk, err := ec.Buyer.SellerServices.GetSellerServicesUsingGET(params, token)
 	if err != nil {
-		switch t := err.(type) {
-		default:
-			return nil, err
-		case *api_buyer_seller_services.GetProfilesByMetroUsingGETBadRequest:
-			if ec.Debug {
-				fmt.Println(t.Error())
-			}
-			return nil, err
+		if ec.Debug {
+			fmt.Println(err.Error())
 		}
+		return nil, err
 	}
 
 	respSellerProfilesList := L3SellerServices{
-		Items:      respSellPOk.Payload.SellerServices,
+		Collection: api.Collection[*models.SellerService]{Items: respSellPOk.Payload.SellerServices},
 		TotalCount: respSellPOk.Payload.TotalCount,
 		PageSize:   respSellPOk.Payload.PageSize,
 	}
@@ -199,14 +258,19 @@ func (ec *ECXSellerServicesAPI) GetL3SellerServices(metroCode *[]string, pageNum
 	return &respSellerProfilesList, nil
 }
 
-// GetSellerProfileByUUID fetch service profile by uuid
+// GetSellerProfileByUUID calls GetSellerProfileByUUIDWithContext with a background context
 func (ec *ECXSellerServicesAPI) GetSellerProfileByUUID(uuid string) (*api_seller_service_profiles.GetProfileByIDOrNameUsingGETOK, error) {
-	token, err := ec.GetToken()
+	return ec.GetSellerProfileByUUIDWithContext(context.Background(), uuid)
+}
+
+// GetSellerProfileByUUIDWithContext fetch service profile by uuid
+func (ec *ECXSellerServicesAPI) GetSellerProfileByUUIDWithContext(ctx context.Context, uuid string) (*api_seller_service_profiles.GetProfileByIDOrNameUsingGETOK, error) {
+	token, err := ec.GetTokenWithContext(ctx)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
-	params := api_seller_service_profiles.NewGetProfileByIDOrNameUsingGETParams()
+	params := api_seller_service_profiles.NewGetProfileByIDOrNameUsingGETParamsWithContext(ctx)
 
 	params.UUID = uuid
 
@@ -222,14 +286,19 @@ func (ec *ECXSellerServicesAPI) GetSellerProfileByUUID(uuid string) (*api_seller
 	return nil, nil
 }
 
-// ValidateIntegrationID validates profile integrationId and returns true only if state == VALID
+// ValidateIntegrationID calls ValidateIntegrationIDWithContext with a background context
 func (ec *ECXSellerServicesAPI) ValidateIntegrationID(integrationid string) (bool, error) {
-	token, err := ec.GetToken()
+	return ec.ValidateIntegrationIDWithContext(context.Background(), integrationid)
+}
+
+// ValidateIntegrationIDWithContext validates profile integrationId and returns true only if state == VALID
+func (ec *ECXSellerServicesAPI) ValidateIntegrationIDWithContext(ctx context.Context, integrationid string) (bool, error) {
+	token, err := ec.GetTokenWithContext(ctx)
 	if err != nil {
-		log.Fatal(err)
+		return false, err
 	}
 
-	params := api_seller_service_profiles.NewValidateIntegrationIDUsingGETParams()
+	params := api_seller_service_profiles.NewValidateIntegrationIDUsingGETParamsWithContext(ctx)
 	params.IntegrationID = integrationid
 
 	idRespOK, idRespNC, err := ec.Seller.ServiceProfiles.ValidateIntegrationIDUsingGET(params, token)
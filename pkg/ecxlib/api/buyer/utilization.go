@@ -0,0 +1,142 @@
+package buyer
+
+import (
+	"context"
+	"math"
+	"sort"
+
+	api "github.com/jxoir/equinix-tools/pkg/ecxlib/api/client"
+	"github.com/jxoir/go-ecxfabric/buyer/models"
+)
+
+// Utilization states of PortUtilization, in increasing severity
+const (
+	UtilizationOK       = "OK"
+	UtilizationWarning  = "WARNING"
+	UtilizationCritical = "CRITICAL"
+)
+
+// PortUtilization bandwidth provisioned by the buyer connections of a port against its capacity, in Mbps
+type PortUtilization struct {
+	PortUUID        string  `json:"portUUID"`
+	PortName        string  `json:"portName"`
+	MetroCode       string  `json:"metroCode"`
+	CapacityMbps    float64 `json:"capacityMbps"`
+	ProvisionedMbps float64 `json:"provisionedMbps"`
+	Connections     int     `json:"connections"`
+	// UtilizationPercent provisioned bandwidth as a percentage of the capacity, above 100 the port is oversubscribed
+	UtilizationPercent float64 `json:"utilizationPercent"`
+	// Oversubscription provisioned to capacity ratio
+	Oversubscription float64 `json:"oversubscription"`
+	// HeadroomMbps bandwidth left for new connections, negative on oversubscribed ports
+	HeadroomMbps float64 `json:"headroomMbps"`
+	// State set by CheckThresholds
+	State string `json:"state,omitempty"`
+}
+
+// PortUtilizationResponse utilization of every port, sorted by port name
+type PortUtilizationResponse struct {
+	api.Collection[*PortUtilization]
+}
+
+// CheckThresholds sets the State of every port comparing its UtilizationPercent with the warning and critical
+// percentages (0 disables a threshold) and returns the most severe state
+func (r *PortUtilizationResponse) CheckThresholds(warning float64, critical float64) string {
+	worst := UtilizationOK
+	for _, port := range r.Items {
+		port.State = UtilizationOK
+		switch {
+		case critical > 0 && port.UtilizationPercent >= critical:
+			port.State = UtilizationCritical
+			worst = UtilizationCritical
+		case warning > 0 && port.UtilizationPercent >= warning:
+			port.State = UtilizationWarning
+			if worst == UtilizationOK {
+				worst = UtilizationWarning
+			}
+		}
+	}
+	return worst
+}
+
+// portCapacityMbps returns the port capacity, ECX reports the bandwidth in bps either for the whole port or for each
+// physical port of a LAG
+func portCapacityMbps(port *models.UserPortResObj) float64 {
+	bandwidth := port.TotalBandwidth
+	if bandwidth == 0 {
+		for _, userPort := range port.UserPorts {
+			if userPort != nil {
+				bandwidth += userPort.Bandwidth
+			}
+		}
+	}
+	return float64(bandwidth) / 1e6
+}
+
+// PortUtilizationReport joins ports with the active conns using them, on their a-side or z-side. Ratios are rounded
+// to two decimals.
+func PortUtilizationReport(ports []*models.UserPortResObj, conns []*models.GetBuyerConResContent) *PortUtilizationResponse {
+	report := &PortUtilizationResponse{}
+	byUUID := map[string]*PortUtilization{}
+	for _, port := range ports {
+		if port == nil {
+			continue
+		}
+		utilization := &PortUtilization{
+			PortUUID:     port.UUID,
+			PortName:     port.Name,
+			MetroCode:    port.MetroCode,
+			CapacityMbps: portCapacityMbps(port),
+		}
+		byUUID[port.UUID] = utilization
+		report.Items = append(report.Items, utilization)
+	}
+
+	for _, conn := range conns {
+		if conn == nil || containsStatus(inactiveStatuses, conn.Status) {
+			continue
+		}
+		speed := speedMbps(float64(conn.Speed), conn.SpeedUnit)
+		for _, portUUID := range []string{conn.PortUUID, conn.ZSidePortUUID} {
+			if port, ok := byUUID[portUUID]; ok {
+				port.ProvisionedMbps += speed
+				port.Connections++
+			}
+		}
+	}
+
+	for _, port := range report.Items {
+		port.HeadroomMbps = port.CapacityMbps - port.ProvisionedMbps
+		if port.CapacityMbps > 0 {
+			ratio := port.ProvisionedMbps / port.CapacityMbps
+			port.Oversubscription = math.Round(ratio*100) / 100
+			port.UtilizationPercent = math.Round(ratio*10000) / 100
+		}
+	}
+
+	sort.SliceStable(report.Items, func(i, j int) bool {
+		return report.Items[i].PortName < report.Items[j].PortName
+	})
+	return report
+}
+
+// GetPortUtilization calls GetPortUtilizationWithContext with a background context
+func (ec *ECXPortsAPI) GetPortUtilization(ecxconnections *ECXConnectionsAPI) (*PortUtilizationResponse, error) {
+	return ec.GetPortUtilizationWithContext(context.Background(), ecxconnections)
+}
+
+// GetPortUtilizationWithContext returns the utilization of every buyer port, requires ECXConnectionsAPI to fetch the
+// connections
+func (ec *ECXPortsAPI) GetPortUtilizationWithContext(ctx context.Context, ecxconnections *ECXConnectionsAPI) (*PortUtilizationResponse, error) {
+	ports, err := ec.GetAllPortsWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	conns, err := ecxconnections.GetAllBuyerConnectionsWithContext(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return PortUtilizationReport(ports.Items, conns.Items), nil
+}
@@ -0,0 +1,40 @@
+package buyer
+
+import (
+	"testing"
+
+	"github.com/jxoir/go-ecxfabric/buyer/models"
+)
+
+func TestPortUtilizationReport(t *testing.T) {
+	ports := []*models.UserPortResObj{
+		{UUID: "port-1", Name: "B-PORT", TotalBandwidth: 1000000000},
+		{UUID: "port-2", Name: "A-PORT", UserPorts: []*models.UserPortsObj{{Bandwidth: 10000000000}, {Bandwidth: 10000000000}}},
+	}
+	conns := []*models.GetBuyerConResContent{
+		{PortUUID: "port-1", Speed: 500, SpeedUnit: "MB", Status: "PROVISIONED"},
+		{PortUUID: "port-1", Speed: 1, SpeedUnit: "GB", Status: "PROVISIONING"},
+		{PortUUID: "port-1", Speed: 10, SpeedUnit: "GB", Status: ConnectionStatusDeprovisioned},
+		{PortUUID: "port-3", ZSidePortUUID: "port-2", Speed: 2, SpeedUnit: "GB", Status: "PROVISIONED"},
+	}
+
+	report := PortUtilizationReport(ports, conns)
+	if report.Count() != 2 || report.Items[0].PortUUID != "port-2" {
+		t.Fatalf("Expected 2 ports sorted by name, received %+v", report.Items)
+	}
+
+	lag, port := report.Items[0], report.Items[1]
+	if lag.CapacityMbps != 20000 || lag.ProvisionedMbps != 2000 || lag.Connections != 1 || lag.UtilizationPercent != 10 {
+		t.Errorf("Unexpected LAG utilization %+v", lag)
+	}
+	if port.CapacityMbps != 1000 || port.ProvisionedMbps != 1500 || port.Connections != 2 || port.Oversubscription != 1.5 || port.HeadroomMbps != -500 {
+		t.Errorf("Unexpected port utilization %+v", port)
+	}
+
+	if state := report.CheckThresholds(80, 0); state != UtilizationWarning || port.State != UtilizationWarning || lag.State != UtilizationOK {
+		t.Errorf("Expected WARNING, received %s (%s, %s)", state, port.State, lag.State)
+	}
+	if state := report.CheckThresholds(5, 100); state != UtilizationCritical || port.State != UtilizationCritical || lag.State != UtilizationWarning {
+		t.Errorf("Expected CRITICAL, received %s (%s, %s)", state, port.State, lag.State)
+	}
+}
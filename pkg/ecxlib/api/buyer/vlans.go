@@ -0,0 +1,122 @@
+package buyer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	client "github.com/jxoir/equinix-tools/pkg/ecxlib/api/client"
+	"github.com/jxoir/go-ecxfabric/buyer/models"
+)
+
+// Valid vlan ids for connection S-Tags
+const (
+	MinVlan int64 = 2
+	MaxVlan int64 = 4094
+)
+
+// ErrNoFreeVlan returned by AllocateSTag when every S-Tag of the range is in use
+var ErrNoFreeVlan = errors.New("no free vlan")
+
+// PortVlan a vlan used on a port by a buyer connection, CTag is only known for z-side (QinQ) connections
+type PortVlan struct {
+	STag           int64  `json:"sTag"`
+	CTag           int64  `json:"cTag,omitempty"`
+	ConnectionUUID string `json:"connectionUUID"`
+	ConnectionName string `json:"connectionName"`
+	Status         string `json:"status"`
+}
+
+// PortVlansResponse vlans used on a port, sorted by S-Tag and C-Tag
+type PortVlansResponse struct {
+	PortUUID string
+	client.Collection[*PortVlan]
+}
+
+// IsUsed returns true if a connection uses sTag as outer vlan
+func (r *PortVlansResponse) IsUsed(sTag int64) bool {
+	for _, vlan := range r.Items {
+		if vlan.STag == sTag {
+			return true
+		}
+	}
+	return false
+}
+
+// FreeSTags returns the S-Tags between min and max (both included) not used by any connection
+func (r *PortVlansResponse) FreeSTags(min int64, max int64) []int64 {
+	used := map[int64]bool{}
+	for _, vlan := range r.Items {
+		used[vlan.STag] = true
+	}
+
+	var free []int64
+	for tag := min; tag <= max; tag++ {
+		if !used[tag] {
+			free = append(free, tag)
+		}
+	}
+	return free
+}
+
+// PortVlansFromConnections returns the vlans used on portUUID by the a-side and z-side of conns, connections being
+// deleted don't hold their vlan
+func PortVlansFromConnections(portUUID string, conns []*models.GetBuyerConResContent) *PortVlansResponse {
+	vlans := &PortVlansResponse{PortUUID: portUUID}
+	for _, conn := range conns {
+		if conn == nil || containsStatus(inactiveStatuses, conn.Status) {
+			continue
+		}
+		if conn.PortUUID == portUUID && conn.VlanSTag != 0 {
+			vlans.Items = append(vlans.Items, &PortVlan{STag: conn.VlanSTag, ConnectionUUID: conn.UUID, ConnectionName: conn.Name, Status: conn.Status})
+		}
+		if conn.ZSidePortUUID == portUUID && conn.ZSideVlanSTag != 0 {
+			vlans.Items = append(vlans.Items, &PortVlan{STag: conn.ZSideVlanSTag, CTag: conn.ZSideVlanCTag, ConnectionUUID: conn.UUID, ConnectionName: conn.Name, Status: conn.Status})
+		}
+	}
+
+	sort.SliceStable(vlans.Items, func(i, j int) bool {
+		a, b := vlans.Items[i], vlans.Items[j]
+		return a.STag < b.STag || a.STag == b.STag && a.CTag < b.CTag
+	})
+	return vlans
+}
+
+// GetPortVlans calls GetPortVlansWithContext with a background context
+func (m *ECXConnectionsAPI) GetPortVlans(portUUID string) (*PortVlansResponse, error) {
+	return m.GetPortVlansWithContext(context.Background(), portUUID)
+}
+
+// GetPortVlansWithContext returns the vlans used on portUUID by the buyer connections
+func (m *ECXConnectionsAPI) GetPortVlansWithContext(ctx context.Context, portUUID string) (*PortVlansResponse, error) {
+	connList, err := m.GetAllBuyerConnectionsWithContext(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return PortVlansFromConnections(portUUID, connList.Items), nil
+}
+
+// AllocateSTag calls AllocateSTagWithContext with a background context
+func (m *ECXConnectionsAPI) AllocateSTag(portUUID string, min int64, max int64) (int64, error) {
+	return m.AllocateSTagWithContext(context.Background(), portUUID, min, max)
+}
+
+// AllocateSTagWithContext returns the lowest S-Tag between min and max not used on portUUID, ErrNoFreeVlan if all
+// of them are. The vlan isn't reserved, a connection created meanwhile may take it.
+func (m *ECXConnectionsAPI) AllocateSTagWithContext(ctx context.Context, portUUID string, min int64, max int64) (int64, error) {
+	if min < MinVlan || max > MaxVlan || min > max {
+		return 0, fmt.Errorf("invalid vlan range %d-%d, must be within %d-%d", min, max, MinVlan, MaxVlan)
+	}
+
+	vlans, err := m.GetPortVlansWithContext(ctx, portUUID)
+	if err != nil {
+		return 0, err
+	}
+
+	free := vlans.FreeSTags(min, max)
+	if len(free) == 0 {
+		return 0, fmt.Errorf("%w on port %s between %d and %d", ErrNoFreeVlan, portUUID, min, max)
+	}
+	return free[0], nil
+}
@@ -0,0 +1,63 @@
+package buyer
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/jxoir/go-ecxfabric/buyer/models"
+)
+
+func TestPortVlansFromConnections(t *testing.T) {
+	conns := []*models.GetBuyerConResContent{
+		{UUID: "uuid-1", PortUUID: "port-1", VlanSTag: 103, Status: "PROVISIONED"},
+		{UUID: "uuid-2", PortUUID: "port-1", VlanSTag: 101, Status: "PROVISIONING"},
+		{UUID: "uuid-3", PortUUID: "port-1", VlanSTag: 102, Status: ConnectionStatusDeprovisioned},
+		{UUID: "uuid-4", PortUUID: "port-2", VlanSTag: 104, ZSidePortUUID: "port-1", ZSideVlanSTag: 100, ZSideVlanCTag: 20, Status: "PROVISIONED"},
+	}
+
+	vlans := PortVlansFromConnections("port-1", conns)
+	var tags []int64
+	for _, vlan := range vlans.Items {
+		tags = append(tags, vlan.STag)
+	}
+	if !reflect.DeepEqual(tags, []int64{100, 101, 103}) {
+		t.Errorf("Expected used vlans [100 101 103], received %v", tags)
+	}
+	if vlans.Items[0].CTag != 20 || vlans.Items[0].ConnectionUUID != "uuid-4" {
+		t.Errorf("Expected z-side vlan of uuid-4 with c-tag 20, received %+v", vlans.Items[0])
+	}
+
+	free := vlans.FreeSTags(99, 104)
+	if !reflect.DeepEqual(free, []int64{99, 102, 104}) {
+		t.Errorf("Expected free vlans [99 102 104], received %v", free)
+	}
+}
+
+func TestAllocateSTag(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ecx/v3/l2/buyer/connections", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"totalCount":2,"pageSize":2,"content":[
+			{"uuid":"uuid-1","portUUID":"port-1","vlanSTag":100,"status":"PROVISIONED"},
+			{"uuid":"uuid-2","portUUID":"port-1","vlanSTag":101,"status":"PROVISIONED"}]}`)
+	})
+	ec, server := newTestClient(mux)
+	defer server.Close()
+	api := NewECXConnectionsAPI(ec)
+
+	tag, err := api.AllocateSTag("port-1", 100, 200)
+	if err != nil || tag != 102 {
+		t.Errorf("Expected vlan 102, received %d %v", tag, err)
+	}
+
+	if _, err := api.AllocateSTag("port-1", 100, 101); !errors.Is(err, ErrNoFreeVlan) {
+		t.Errorf("Expected ErrNoFreeVlan, received %v", err)
+	}
+
+	if _, err := api.AllocateSTag("port-1", 0, 5000); err == nil {
+		t.Errorf("Expected invalid range error")
+	}
+}
@@ -15,9 +15,12 @@
 package client
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
+	"sync"
+	"time"
 
 	"crypto/tls"
 
@@ -41,16 +44,36 @@ type EquinixAPIParams struct {
 	Endpoint        string
 	PlaygroundToken string
 	Debug           bool
+	// TokenCache optional cache to share tokens between client instances
+	TokenCache TokenCache
+	// RateLimit maximum requests per second sent to ECX, 0 disables the limiter
+	RateLimit float64
+	// RateLimitBurst requests allowed in a burst above RateLimit
+	RateLimitBurst int
 }
 
 // EquinixAPIClient containing structure for Client, params and apitoken
-// TODO: Implement token refresh
 type EquinixAPIClient struct {
 	Buyer    *apibuyerclient.GoEcxfabricBuyer
 	Seller   *apisellerclient.GoEcxfabricSeller
 	Params   *EquinixAPIParams
 	apiToken runtime.ClientAuthInfoWriter
 	Debug    bool
+	// RetryPolicy applied to every API call, nil disables retries
+	RetryPolicy *RetryPolicy
+	// PageConcurrency pages fetched at the same time by list calls
+	PageConcurrency int
+	// PollPolicy waits between polls of the WaitFor calls, nil uses DefaultPollPolicy
+	PollPolicy *PollPolicy
+
+	// transport is the swagger transport shared by Buyer and Seller clients
+	transport runtime.ClientTransport
+	// tokenMu guards apiToken and the token expiry/refresh information
+	tokenMu               sync.Mutex
+	accessToken           string
+	tokenExpiresAt        time.Time
+	refreshToken          string
+	refreshTokenExpiresAt time.Time
 }
 
 const (
@@ -62,65 +85,117 @@ const (
 type APIHandler interface {
 	Authenticate() error
 	GetToken() (runtime.ClientAuthInfoWriter, error)
+	GetTokenWithContext(ctx context.Context) (runtime.ClientAuthInfoWriter, error)
 }
 
 var defaultGrantType = "client_credentials"
 
 // NewEcxAPIClient returns an instantiated ECX client with token
 func NewEcxAPIClient(params *EquinixAPIParams, endpoint string, ignoreSSL bool) *EquinixAPIClient {
-	var equinixAPIClient *EquinixAPIClient
+	var httpClient *http.Client
+	var roundTripper http.RoundTripper
 	if ignoreSSL != false {
 		log.Println(" - Insecure mode, ingoring SSL certificate")
 
-		tr := &http.Transport{
+		roundTripper = &http.Transport{
 			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
 		}
-		client := &http.Client{Transport: tr}
-		transport := httptransport.NewWithClient(endpoint, "", nil, client)
-		ecxBuyerAPIClient := apibuyerclient.New(transport, strfmt.Default)
-		ecxSellerAPIClient := apisellerclient.New(transport, strfmt.Default)
-
-		equinixAPIClient = &EquinixAPIClient{
-			Params: params,
-			Seller: ecxSellerAPIClient,
-			Buyer:  ecxBuyerAPIClient,
-			Debug:  params.Debug,
-		}
+	}
 
-	} else {
-		// create the transport
-		transport := httptransport.New(endpoint, "", nil)
-		// create the API client, with the transport
-		ecxBuyerAPIClient := apibuyerclient.New(transport, strfmt.Default)
-		ecxSellerAPIClient := apisellerclient.New(transport, strfmt.Default)
-
-		equinixAPIClient = &EquinixAPIClient{
-			Params: params,
-			Seller: ecxSellerAPIClient,
-			Buyer:  ecxBuyerAPIClient,
-			Debug:  params.Debug,
+	if params.RateLimit > 0 {
+		if roundTripper == nil {
+			roundTripper = http.DefaultTransport
+		}
+		roundTripper = &rateLimitedTransport{
+			limiter:   NewRateLimiter(params.RateLimit, params.RateLimitBurst),
+			transport: roundTripper,
 		}
 	}
 
+	if roundTripper != nil {
+		httpClient = &http.Client{Transport: roundTripper}
+	}
+
+	equinixAPIClient := &EquinixAPIClient{
+		Params:          params,
+		Debug:           params.Debug,
+		RetryPolicy:     DefaultRetryPolicy(),
+		PageConcurrency: DefaultPageConcurrency,
+	}
+
+	// create the transport, every operation goes through apiTransport so expired tokens are renewed
+	transport := &apiTransport{
+		transport: httptransport.NewWithClient(endpoint, "", nil, httpClient),
+		client:    equinixAPIClient,
+	}
+	equinixAPIClient.transport = transport
+
+	// create the API clients, with the transport
+	equinixAPIClient.Buyer = apibuyerclient.New(transport, strfmt.Default)
+	equinixAPIClient.Seller = apisellerclient.New(transport, strfmt.Default)
+
 	return equinixAPIClient
 }
 
-// GetToken returns local token, if token doesn't exists tries to authenticate and retrieve token
+// Submit sends an operation not covered by the generated Buyer and Seller clients, through the same transport so
+// it is retried and re-authenticated like any other call
+func (ec *EquinixAPIClient) Submit(op *runtime.ClientOperation) (interface{}, error) {
+	return ec.transport.Submit(op)
+}
+
+// GetToken calls GetTokenWithContext with a background context
 func (ec *EquinixAPIClient) GetToken() (runtime.ClientAuthInfoWriter, error) {
+	return ec.GetTokenWithContext(context.Background())
+}
+
+// GetTokenWithContext returns local token, if token doesn't exists or is about to expire tries to refresh or
+// authenticate and retrieve token, ctx bounds the OAuth calls
+func (ec *EquinixAPIClient) GetTokenWithContext(ctx context.Context) (runtime.ClientAuthInfoWriter, error) {
+	ec.tokenMu.Lock()
+	defer ec.tokenMu.Unlock()
+
 	if ec.apiToken == nil {
+		ec.loadCachedToken()
+	}
+
+	if ec.apiToken != nil && !ec.tokenExpired() {
+		return ec.apiToken, nil
+	}
 
-		err := ec.Authenticate()
-		if err != nil {
-			return nil, err
+	if ec.apiToken != nil && ec.canRefresh() {
+		err := ec.refresh(ctx)
+		if err == nil {
+			return ec.apiToken, nil
+		}
+		if ec.Debug {
+			log.Println("Failed to refresh token, authenticating again: " + err.Error())
 		}
 	}
 
+	err := ec.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	return ec.apiToken, nil
 
 }
 
-// Authenticate tries to authenticate and stores token from remote endpoint
+// Authenticate calls AuthenticateWithContext with a background context
 func (ec *EquinixAPIClient) Authenticate() error {
+	return ec.AuthenticateWithContext(context.Background())
+}
+
+// AuthenticateWithContext tries to authenticate and stores token from remote endpoint
+func (ec *EquinixAPIClient) AuthenticateWithContext(ctx context.Context) error {
+	ec.tokenMu.Lock()
+	defer ec.tokenMu.Unlock()
+
+	return ec.authenticate(ctx)
+}
+
+// authenticate requests a new token with the client credentials, tokenMu must be held
+func (ec *EquinixAPIClient) authenticate(ctx context.Context) error {
 	// set default parameters
 	if ec.Params.PlaygroundToken != "" {
 		// we are going to use playground mode, that means fixed token for each request
@@ -131,19 +206,19 @@ func (ec *EquinixAPIClient) Authenticate() error {
 		return nil
 	}
 	if ec.Params.AppID == "" {
-		log.Fatal("EQUINIX_API_ID not set")
+		return ErrAppIDNotSet
 	}
 	if ec.Params.AppSecret == "" {
-		log.Fatal("EQUINIX_API_SECRET not set")
+		return ErrAppSecretNotSet
 	}
 	if ec.Params.Endpoint == "" {
-		log.Fatal("ECX_API_HOST not specified")
+		return ErrEndpointNotSet
 	}
 	if ec.Params.GrantType == "" {
-		ec.Params.AppSecret = defaultGrantType
+		ec.Params.GrantType = defaultGrantType
 	}
 
-	accessTokenParams := access_token.NewGetAccessTokenParams()
+	accessTokenParams := access_token.NewGetAccessTokenParamsWithContext(ctx)
 	accessTokenRequest := models.OAuthRequest{
 		ClientID:     ec.Params.AppID,
 		ClientSecret: ec.Params.AppSecret,
@@ -167,9 +242,8 @@ func (ec *EquinixAPIClient) Authenticate() error {
 		log.Println("Token acquired...")
 	}
 
-	bearerTokenAuth := httptransport.BearerToken(accessToken.Payload.AccessToken)
-
-	ec.apiToken = bearerTokenAuth
+	ec.setToken(accessToken.Payload)
+	ec.storeCachedToken()
 
 	if ec.Debug {
 		log.Println("User:" + ec.Params.UserName)
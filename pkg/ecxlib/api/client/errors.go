@@ -0,0 +1,184 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/go-openapi/runtime"
+)
+
+var (
+	// ErrAppIDNotSet returned when authenticating without EQUINIX_API_ID
+	ErrAppIDNotSet = errors.New("EQUINIX_API_ID not set")
+	// ErrAppSecretNotSet returned when authenticating without EQUINIX_API_SECRET
+	ErrAppSecretNotSet = errors.New("EQUINIX_API_SECRET not set")
+	// ErrEndpointNotSet returned when authenticating without ECX_API_HOST
+	ErrEndpointNotSet = errors.New("ECX_API_HOST not specified")
+)
+
+// ErrorMessage single error message returned by ECX
+type ErrorMessage struct {
+	ErrorCode string `json:"errorCode,omitempty"`
+	Message   string `json:"errorMessage,omitempty"`
+	Property  string `json:"property,omitempty"`
+	MoreInfo  string `json:"moreInfo,omitempty"`
+}
+
+// APIError structured error for ECX API responses, the first ECX error message is promoted to the top level fields
+type APIError struct {
+	// StatusCode HTTP status code of the response
+	StatusCode int
+	// Operation swagger operation id
+	Operation string
+	ErrorCode string
+	Message   string
+	Property  string
+	MoreInfo  string
+	// Errors every error message in the response payload
+	Errors []ErrorMessage
+	// Err original swagger error
+	Err error
+}
+
+// Error returns the ECX error code and message along with the HTTP status
+func (e *APIError) Error() string {
+	msg := e.Message
+	if e.ErrorCode != "" {
+		msg = e.ErrorCode + ": " + msg
+	}
+	if e.Property != "" {
+		msg += " (property " + e.Property + ")"
+	}
+	if e.MoreInfo != "" {
+		msg += " - " + e.MoreInfo
+	}
+	if e.StatusCode != 0 {
+		msg = fmt.Sprintf("%s [status %d]", msg, e.StatusCode)
+	}
+	return msg
+}
+
+// Unwrap returns the original swagger error
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// errorPayload union of the error payloads found in ECX swagger specs (ErrorResponse, GeneralErrorMessageDetail,
+// GetRoutingInstanceErrorMessage and OAuthErrorResponse)
+type errorPayload struct {
+	ErrorCode        string `json:"errorCode"`
+	Code             string `json:"code"`
+	ErrorMessage     string `json:"errorMessage"`
+	Message          string `json:"message"`
+	DeveloperMessage string `json:"developerMessage"`
+	Property         string `json:"property"`
+	MoreInfo         string `json:"moreInfo"`
+}
+
+func (p errorPayload) errorMessage() ErrorMessage {
+	m := ErrorMessage{
+		ErrorCode: p.ErrorCode,
+		Message:   p.ErrorMessage,
+		Property:  p.Property,
+		MoreInfo:  p.MoreInfo,
+	}
+	if m.ErrorCode == "" {
+		m.ErrorCode = p.Code
+	}
+	if m.Message == "" {
+		m.Message = p.Message
+	}
+	if m.Message == "" {
+		m.Message = p.DeveloperMessage
+	}
+	return m
+}
+
+// NewAPIError builds an APIError from a swagger error, statusCode is used when the error doesn't carry one.
+// Errors without an ECX response (network, decoding...) are returned untouched.
+func NewAPIError(err error, operation string, statusCode int) error {
+	if err == nil {
+		return nil
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return err
+	}
+
+	apiErr = &APIError{
+		StatusCode: statusCode,
+		Operation:  operation,
+		Err:        err,
+	}
+
+	if swaggerErr, ok := err.(*runtime.APIError); ok {
+		// unknown response code for the operation, there is no payload we can read
+		if swaggerErr.Code != 0 {
+			apiErr.StatusCode = swaggerErr.Code
+		}
+		if apiErr.Operation == "" {
+			apiErr.Operation = swaggerErr.OperationName
+		}
+		apiErr.Message = fmt.Sprintf("unexpected response from %s", apiErr.Operation)
+		return apiErr
+	}
+
+	// generated swagger errors hold the response in a Payload field
+	v := reflect.Indirect(reflect.ValueOf(err))
+	if v.Kind() != reflect.Struct {
+		return err
+	}
+	payload := v.FieldByName("Payload")
+	if !payload.IsValid() {
+		return err
+	}
+
+	apiErr.Errors = parseErrorPayload(payload.Interface())
+	if len(apiErr.Errors) > 0 {
+		first := apiErr.Errors[0]
+		apiErr.ErrorCode = first.ErrorCode
+		apiErr.Message = first.Message
+		apiErr.Property = first.Property
+		apiErr.MoreInfo = first.MoreInfo
+	}
+	if apiErr.Message == "" {
+		apiErr.Message = err.Error()
+	}
+
+	return apiErr
+}
+
+// parseErrorPayload converts any of the ECX error payloads (single or array) to a slice of ErrorMessage
+func parseErrorPayload(payload interface{}) []ErrorMessage {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil
+	}
+
+	var list []errorPayload
+	if err := json.Unmarshal(data, &list); err != nil {
+		var single errorPayload
+		if err := json.Unmarshal(data, &single); err != nil {
+			return nil
+		}
+		list = []errorPayload{single}
+	}
+
+	messages := make([]ErrorMessage, 0, len(list))
+	for _, p := range list {
+		m := p.errorMessage()
+		if m != (ErrorMessage{}) {
+			messages = append(messages, m)
+		}
+	}
+	return messages
+}
+
+// IsStatus returns true if err is an APIError with the given HTTP status code
+func IsStatus(err error, statusCode int) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == statusCode
+}
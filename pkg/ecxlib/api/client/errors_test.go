@@ -0,0 +1,54 @@
+package client
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	apiconnections "github.com/jxoir/go-ecxfabric/buyer/client/connections"
+	"github.com/jxoir/go-ecxfabric/buyer/models"
+)
+
+func TestNewAPIErrorFromBadRequest(t *testing.T) {
+	badRequest := &apiconnections.CreateConnectionUsingPOSTBadRequest{
+		Payload: models.ErrorResponseArray{
+			{ErrorCode: "IC-LAYER2-4021", ErrorMessage: "Invalid vlan", Property: "primaryVlanSTag", MoreInfo: "vlan in use"},
+			{ErrorCode: "IC-LAYER2-4022", ErrorMessage: "Invalid speed"},
+		},
+	}
+
+	err := NewAPIError(badRequest, "createConnectionUsingPOST", http.StatusBadRequest)
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Expected APIError, received %T", err)
+	}
+	if apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected status 400, received %d", apiErr.StatusCode)
+	}
+	if apiErr.ErrorCode != "IC-LAYER2-4021" || apiErr.Property != "primaryVlanSTag" || apiErr.MoreInfo != "vlan in use" {
+		t.Errorf("Expected first error message promoted, received %+v", apiErr)
+	}
+	if len(apiErr.Errors) != 2 {
+		t.Errorf("Expected 2 error messages, received %d", len(apiErr.Errors))
+	}
+	if apiErr.Unwrap() != badRequest {
+		t.Errorf("Expected original swagger error to be wrapped")
+	}
+	if !IsStatus(err, http.StatusBadRequest) {
+		t.Errorf("Expected IsStatus to match 400")
+	}
+}
+
+func TestNewAPIErrorFromRoutingInstanceMessage(t *testing.T) {
+	payload := &models.GetRoutingInstanceErrorMessage{Code: "IC-RI-001", Message: "Routing instance not found", Status: "404"}
+	err := NewAPIError(&struct{ error }{errors.New("not found")}, "", http.StatusNotFound)
+	if _, ok := err.(*APIError); ok {
+		t.Errorf("Expected errors without payload to be returned untouched")
+	}
+
+	messages := parseErrorPayload(payload)
+	if len(messages) != 1 || messages[0].ErrorCode != "IC-RI-001" || messages[0].Message != "Routing instance not found" {
+		t.Errorf("Expected code and message to be parsed, received %+v", messages)
+	}
+}
@@ -0,0 +1,549 @@
+package client
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Filter expression matching response items, see ParseFilter
+type Filter interface {
+	Match(item interface{}) bool
+}
+
+// FilterSyntaxError error parsing a filter expression at position Pos
+type FilterSyntaxError struct {
+	Expr string
+	Pos  int
+	Msg  string
+}
+
+// Error returns the error message along with the position in the expression
+func (e *FilterSyntaxError) Error() string {
+	return fmt.Sprintf("invalid filter %q at position %d: %s", e.Expr, e.Pos+1, e.Msg)
+}
+
+// filter operators, longest first so "!=" isn't read as "!"
+var filterOperators = []string{"!=", "!~", "<=", ">=", "==", "=", "~", "<", ">"}
+
+// filterTimeLayouts layouts tried when comparing dates
+var filterTimeLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02"}
+
+type andFilter struct{ left, right Filter }
+
+func (f *andFilter) Match(item interface{}) bool { return f.left.Match(item) && f.right.Match(item) }
+
+type orFilter struct{ left, right Filter }
+
+func (f *orFilter) Match(item interface{}) bool { return f.left.Match(item) || f.right.Match(item) }
+
+type notFilter struct{ filter Filter }
+
+func (f *notFilter) Match(item interface{}) bool { return !f.filter.Match(item) }
+
+// conditionFilter compares the field of an item against a literal value, negated conditions ("!=", "!~") are
+// stored with the positive operator and negate set
+type conditionFilter struct {
+	field  string
+	op     string
+	negate bool
+	value  string
+	re     *regexp.Regexp
+}
+
+// containsOp operator used by ResponseFilter, substring match on strings and equality on everything else
+const containsOp = "contains"
+
+// ContainsFilter returns a filter matching items whose field contains value, string fields match on substrings and
+// any other field on equality, as ResponseFilter does
+func ContainsFilter(field string, value string) Filter {
+	return &conditionFilter{field: field, op: containsOp, value: value}
+}
+
+// EqualFilter returns a filter matching items whose field equals value, as the "=" operator of ParseFilter does
+func EqualFilter(field string, value string) Filter {
+	return &conditionFilter{field: field, op: "=", value: value}
+}
+
+// Match returns true if any value found at the field path satisfies the condition, negated conditions match when
+// no value does. Items without the field never match.
+func (f *conditionFilter) Match(item interface{}) bool {
+	values := resolveField(reflect.ValueOf(item), strings.Split(f.field, "."))
+	if len(values) == 0 {
+		return false
+	}
+	for _, v := range values {
+		if f.matchValue(v) {
+			return !f.negate
+		}
+	}
+	return f.negate
+}
+
+// matchValue compares a single field value, nil values never match
+func (f *conditionFilter) matchValue(v reflect.Value) bool {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return false
+		}
+		v = v.Elem()
+	}
+
+	switch f.op {
+	case "~":
+		return f.re.MatchString(formatValue(v))
+	case containsOp:
+		if v.Kind() == reflect.String {
+			return strings.Contains(v.String(), f.value)
+		}
+		return f.compare(v) == 0
+	case "=":
+		return f.compare(v) == 0
+	}
+
+	c := f.compare(v)
+	if c == incomparable {
+		return false
+	}
+	switch f.op {
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	}
+	return false
+}
+
+// incomparable returned by compare when the value and the literal have different types
+const incomparable = 2
+
+// compare returns -1, 0 or 1 comparing v with the condition value, or incomparable
+func (f *conditionFilter) compare(v reflect.Value) int {
+	switch v.Kind() {
+	case reflect.String:
+		s := v.String()
+		// numbers and dates are transported as strings quite often
+		if a, err := strconv.ParseFloat(s, 64); err == nil {
+			if b, err := strconv.ParseFloat(f.value, 64); err == nil {
+				return compareFloat(a, b)
+			}
+		}
+		if a, ok := parseFilterTime(s); ok {
+			if b, ok := parseFilterTime(f.value); ok {
+				return compareTime(a, b)
+			}
+		}
+		return strings.Compare(s, f.value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		b, err := strconv.ParseFloat(f.value, 64)
+		if err != nil {
+			return incomparable
+		}
+		return compareFloat(float64(v.Int()), b)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		b, err := strconv.ParseFloat(f.value, 64)
+		if err != nil {
+			return incomparable
+		}
+		return compareFloat(float64(v.Uint()), b)
+	case reflect.Float32, reflect.Float64:
+		b, err := strconv.ParseFloat(f.value, 64)
+		if err != nil {
+			return incomparable
+		}
+		return compareFloat(v.Float(), b)
+	case reflect.Struct:
+		if a, ok := timeValue(v); ok {
+			b, ok := parseFilterTime(f.value)
+			if !ok {
+				return incomparable
+			}
+			return compareTime(a, b)
+		}
+	case reflect.Bool:
+		b, err := strconv.ParseBool(f.value)
+		if err != nil {
+			return incomparable
+		}
+		if v.Bool() == b {
+			return 0
+		}
+		// booleans have no order
+		return incomparable
+	}
+	return incomparable
+}
+
+func compareFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareTime(a, b time.Time) int {
+	switch {
+	case a.Before(b):
+		return -1
+	case a.After(b):
+		return 1
+	}
+	return 0
+}
+
+func parseFilterTime(s string) (time.Time, bool) {
+	for _, layout := range filterTimeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// timeValue returns v as a time.Time for time.Time and its derived types (strfmt.DateTime, strfmt.Date)
+func timeValue(v reflect.Value) (time.Time, bool) {
+	if v.Kind() != reflect.Struct || !v.Type().ConvertibleTo(timeType) || !v.CanInterface() {
+		return time.Time{}, false
+	}
+	return v.Convert(timeType).Interface().(time.Time), true
+}
+
+// formatValue returns the string representation used by regular expression matches
+func formatValue(v reflect.Value) string {
+	if v.Kind() == reflect.String {
+		return v.String()
+	}
+	if t, ok := timeValue(v); ok {
+		return t.Format(time.RFC3339Nano)
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// resolveField returns the values found at path (a dotted field path split in segments) starting at v. Slices
+// are traversed matching every element unless the segment is an index, maps are indexed by key. The values found
+// at the end of the path are expanded when they are slices, so conditions match any element.
+func resolveField(v reflect.Value, path []string) []reflect.Value {
+	return resolvePath(v, path, true)
+}
+
+// resolvePath resolves path as resolveField does, slices at the end of the path are only expanded if expandLeaf
+func resolvePath(v reflect.Value, path []string, expandLeaf bool) []reflect.Value {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			if len(path) == 0 {
+				return []reflect.Value{v}
+			}
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	if (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) && (len(path) > 0 || expandLeaf) {
+		if len(path) > 0 {
+			if i, err := strconv.Atoi(path[0]); err == nil {
+				if i < 0 || i >= v.Len() {
+					return nil
+				}
+				return resolvePath(v.Index(i), path[1:], expandLeaf)
+			}
+		}
+		var values []reflect.Value
+		for i := 0; i < v.Len(); i++ {
+			values = append(values, resolvePath(v.Index(i), path, expandLeaf)...)
+		}
+		return values
+	}
+
+	if len(path) == 0 {
+		return []reflect.Value{v}
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		field, ok := lookupField(v, path[0])
+		if !ok {
+			return nil
+		}
+		return resolvePath(field, path[1:], expandLeaf)
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil
+		}
+		value := v.MapIndex(reflect.ValueOf(path[0]).Convert(v.Type().Key()))
+		if !value.IsValid() {
+			return nil
+		}
+		return resolvePath(value, path[1:], expandLeaf)
+	}
+	return nil
+}
+
+// lookupField returns the field of a struct named as its json tag, falling back to a case insensitive match on the
+// json tag or the field name
+func lookupField(v reflect.Value, name string) (reflect.Value, bool) {
+	t := v.Type()
+	fallback := -1
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		// skip unexported fields
+		if f.PkgPath != "" {
+			continue
+		}
+		tagName, _ := parseTag(f.Tag.Get("json"))
+		if tagName == name {
+			return v.Field(i), true
+		}
+		if fallback < 0 && (strings.EqualFold(tagName, name) || strings.EqualFold(f.Name, name)) {
+			fallback = i
+		}
+	}
+	if fallback >= 0 {
+		return v.Field(fallback), true
+	}
+	return reflect.Value{}, false
+}
+
+// ResponseFilterExpression keeps the response items matching filter
+func ResponseFilterExpression(response ECXAPIResponse, filter Filter) {
+	items := response.GetItems()
+	newitems := items[:0]
+	for _, item := range items {
+		if filter.Match(item) {
+			newitems = append(newitems, item)
+		}
+	}
+	response.SetItems(newitems)
+}
+
+// ParseFilter parses a filter expression made of conditions "field op value" joined with "and", "or" and "not"
+// (or "&&", "||" and "!", a comma is also an "and") and grouped with parentheses. Fields are json field names,
+// supported operators are "=", "!=", "~" and "!~" (regular expressions), "<", "<=", ">" and ">=" comparing
+// numbers, dates or strings. Values containing spaces, parentheses or commas must be quoted.
+//
+// Nested fields are referenced with dotted paths, conditions on slices match if any element matches (or a
+// specific one with its index) and maps are indexed by key.
+//
+//	speed>=500 and status=PROVISIONED and createdDate>2024-01-01
+//	not (name~"^TEST" or redundancyType=secondary)
+//	metadata.integration_id~^AWS and notifications~@equinix.com$
+func ParseFilter(expr string) (Filter, error) {
+	p := &filterParser{expr: expr}
+	p.skipSpace()
+	if p.eof() {
+		return nil, p.errorf("empty expression")
+	}
+
+	f, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.eof() {
+		return nil, p.errorf("unexpected %q", p.expr[p.pos:])
+	}
+	return f, nil
+}
+
+// filterParser recursive descent parser for filter expressions
+type filterParser struct {
+	expr string
+	pos  int
+}
+
+func (p *filterParser) errorf(format string, args ...interface{}) error {
+	return &FilterSyntaxError{Expr: p.expr, Pos: p.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *filterParser) eof() bool {
+	return p.pos >= len(p.expr)
+}
+
+func (p *filterParser) skipSpace() {
+	for !p.eof() && unicode.IsSpace(rune(p.expr[p.pos])) {
+		p.pos++
+	}
+}
+
+// consume skips s if the expression continues with it
+func (p *filterParser) consume(s string) bool {
+	if strings.HasPrefix(p.expr[p.pos:], s) {
+		p.pos += len(s)
+		p.skipSpace()
+		return true
+	}
+	return false
+}
+
+// consumeKeyword skips the keyword (case insensitive) if it's the next word
+func (p *filterParser) consumeKeyword(keyword string) bool {
+	end := p.pos + len(keyword)
+	if end > len(p.expr) || !strings.EqualFold(p.expr[p.pos:end], keyword) {
+		return false
+	}
+	if end < len(p.expr) && isFieldChar(p.expr[end]) {
+		return false
+	}
+	p.pos = end
+	p.skipSpace()
+	return true
+}
+
+func (p *filterParser) parseOr() (Filter, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.consumeKeyword("or") || p.consume("||") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &orFilter{left, right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (Filter, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.consumeKeyword("and") || p.consume("&&") || p.consume(",") {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &andFilter{left, right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseUnary() (Filter, error) {
+	if p.consumeKeyword("not") || p.consume("!") {
+		f, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &notFilter{f}, nil
+	}
+	if p.consume("(") {
+		f, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.consume(")") {
+			return nil, p.errorf("missing closing parenthesis")
+		}
+		return f, nil
+	}
+	return p.parseCondition()
+}
+
+func (p *filterParser) parseCondition() (Filter, error) {
+	start := p.pos
+	for !p.eof() && isFieldChar(p.expr[p.pos]) {
+		p.pos++
+	}
+	if p.pos == start {
+		if p.eof() {
+			return nil, p.errorf("expected field name")
+		}
+		return nil, p.errorf("expected field name, found %q", p.expr[p.pos])
+	}
+	field := p.expr[start:p.pos]
+	p.skipSpace()
+
+	op := ""
+	for _, candidate := range filterOperators {
+		if strings.HasPrefix(p.expr[p.pos:], candidate) {
+			op = candidate
+			break
+		}
+	}
+	if op == "" {
+		return nil, p.errorf("expected operator after %q", field)
+	}
+	p.pos += len(op)
+	p.skipSpace()
+
+	value, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+
+	cond := &conditionFilter{field: field, op: op, value: value}
+	switch op {
+	case "==":
+		cond.op = "="
+	case "!=":
+		cond.op, cond.negate = "=", true
+	case "!~":
+		cond.op, cond.negate = "~", true
+	}
+	if cond.op == "~" {
+		re, err := regexp.Compile(value)
+		if err != nil {
+			return nil, p.errorf("invalid regular expression %q: %s", value, err)
+		}
+		cond.re = re
+	}
+	p.skipSpace()
+	return cond, nil
+}
+
+// parseValue reads a quoted string or a bare word ending at a space, parenthesis or comma
+func (p *filterParser) parseValue() (string, error) {
+	if p.eof() {
+		return "", p.errorf("expected value")
+	}
+
+	if quote := p.expr[p.pos]; quote == '"' || quote == '\'' {
+		start := p.pos
+		var value strings.Builder
+		for p.pos++; !p.eof(); p.pos++ {
+			c := p.expr[p.pos]
+			if c == '\\' && p.pos+1 < len(p.expr) && (p.expr[p.pos+1] == quote || p.expr[p.pos+1] == '\\') {
+				p.pos++
+				value.WriteByte(p.expr[p.pos])
+				continue
+			}
+			if c == quote {
+				p.pos++
+				return value.String(), nil
+			}
+			value.WriteByte(c)
+		}
+		p.pos = start
+		return "", p.errorf("unterminated quoted value")
+	}
+
+	start := p.pos
+	for !p.eof() {
+		c := p.expr[p.pos]
+		if unicode.IsSpace(rune(c)) || c == '(' || c == ')' || c == ',' {
+			break
+		}
+		p.pos++
+	}
+	if p.pos == start {
+		return "", p.errorf("expected value")
+	}
+	return p.expr[start:p.pos], nil
+}
+
+// isFieldChar characters allowed in field names
+func isFieldChar(c byte) bool {
+	return c == '_' || c == '.' || c == '-' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
@@ -0,0 +1,146 @@
+package client
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/jxoir/go-ecxfabric/buyer/models"
+)
+
+func newFilterTestItem(t *testing.T) *models.GETConnectionByUUIDResponse {
+	item := &models.GETConnectionByUUIDResponse{}
+	if err := json.Unmarshal([]byte(responseJSON), item); err != nil {
+		t.Fatalf("Can't unmarshal test JSON into connection struct")
+	}
+	return item
+}
+
+func TestParseFilterMatch(t *testing.T) {
+	item := newFilterTestItem(t)
+
+	tests := []struct {
+		expr  string
+		match bool
+	}{
+		{"status=PROVISIONED", true},
+		{"status!=PROVISIONED", false},
+		{"speed>=50 and status=PROVISIONED", true},
+		{"speed>50", false},
+		{"speed<100 && vlanSTag=3022", true},
+		{"createdDate>2018-01-01 and createdDate<2019-01-01", true},
+		{"createdDate>2024-01-01", false},
+		{"name~^EQUINIX_", true},
+		{"name!~TEST$", false},
+		{`portName~"^EQUINIX-(LD|AM)"`, true},
+		{"metroCode=AM or metroCode=LD", true},
+		{"not (metroCode=AM or metroCode=LD)", false},
+		{"!metroCode=AM", true},
+		{"status=PROVISIONED, speed=100", false},
+		{"Name=EQUINIX_TEST", true},
+		{"unknown=value", false},
+		{"unknown!=value", false},
+		{"speed=fast", false},
+	}
+
+	for _, test := range tests {
+		f, err := ParseFilter(test.expr)
+		if err != nil {
+			t.Errorf("%s: unexpected error %s", test.expr, err)
+			continue
+		}
+		if f.Match(item) != test.match {
+			t.Errorf("%s: expected match %t", test.expr, test.match)
+		}
+	}
+}
+
+func TestParseFilterSyntaxError(t *testing.T) {
+	for _, expr := range []string{"", "status", "status=", "(status=PROVISIONED", "status=PROVISIONED and", "name~'(", "status=PROVISIONED)", `name="EQUINIX`} {
+		_, err := ParseFilter(expr)
+		if _, ok := err.(*FilterSyntaxError); !ok {
+			t.Errorf("%q: expected FilterSyntaxError, received %v", expr, err)
+		}
+	}
+}
+
+func TestResponseFilterExpression(t *testing.T) {
+	resp := ECXAPIResponseMock{}
+	resp.SetItems([]interface{}{newFilterTestItem(t), &models.GETConnectionByUUIDResponse{Status: "DEPROVISIONED"}})
+
+	f, err := ParseFilter("status=PROVISIONED or speed>=1000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ResponseFilterExpression(&resp, f)
+	if resp.Count() != 1 {
+		t.Errorf("Expected 1 item after filtering, received %d", resp.Count())
+	}
+}
+
+func TestParseFilterNestedFields(t *testing.T) {
+	profile := &models.GetServProfServicesRespContent{
+		Name:       "AWS Direct Connect",
+		Private:    false,
+		Metros:     []*models.GetServProfServicesRespContentMetros{{Code: "LD", Ibxs: []string{"LD4", "LD5"}}, {Code: "AM"}},
+		SpeedBands: []*models.SpeedBand{{Speed: 50, Unit: "MB"}, {Speed: 1, Unit: "GB"}},
+	}
+	labels := struct {
+		Labels  map[string]string `json:"labels"`
+		Created time.Time         `json:"created"`
+	}{
+		Labels:  map[string]string{"env": "prod"},
+		Created: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		item  interface{}
+		expr  string
+		match bool
+	}{
+		{newFilterTestItem(t), "metadata.integration_id~^AWS", true},
+		{newFilterTestItem(t), "metadata.notification_emails=jxoir@github.com", true},
+		{newFilterTestItem(t), "notifications!=jxoir@github.com", false},
+		{newFilterTestItem(t), "metadata.missing=value", false},
+		{profile, "metros.code=AM", true},
+		{profile, "metros.0.code=AM", false},
+		{profile, "metros.ibxs=LD5", true},
+		{profile, "speedBands.speed>=50 and speedBands.unit=MB", true},
+		{profile, "speedBands.speed>100", false},
+		{profile, "private=false", true},
+		{labels, "labels.env=prod", true},
+		{labels, "labels.team=network", false},
+		{labels, "created>2024-01-01 and created<2024-06-01T00:00:00Z", true},
+	}
+
+	for _, test := range tests {
+		f, err := ParseFilter(test.expr)
+		if err != nil {
+			t.Errorf("%s: unexpected error %s", test.expr, err)
+			continue
+		}
+		if f.Match(test.item) != test.match {
+			t.Errorf("%s: expected match %t", test.expr, test.match)
+		}
+	}
+}
+
+func TestContainsFilter(t *testing.T) {
+	tests := []struct {
+		item  interface{}
+		field string
+		value string
+		match bool
+	}{
+		{&models.GETConnectionByUUIDResponse{Name: "TEST-CONN"}, "name", "CONN", true},
+		{&models.GETConnectionByUUIDResponse{Speed: 50}, "speed", "50", true},
+		{&models.GETConnectionByUUIDResponse{Speed: 150}, "speed", "50", false},
+		{&models.GETConnectionByUUIDResponse{Speed: 500}, "speed", "50", false},
+	}
+
+	for _, test := range tests {
+		if ContainsFilter(test.field, test.value).Match(test.item) != test.match {
+			t.Errorf("%s=%s on %+v: expected match %t", test.field, test.value, test.item, test.match)
+		}
+	}
+}
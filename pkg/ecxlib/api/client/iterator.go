@@ -0,0 +1,121 @@
+package client
+
+import (
+	"context"
+)
+
+// IteratorPage page of items returned to a PageIterator along with the pagination information
+type IteratorPage struct {
+	Items      []interface{}
+	TotalCount int64
+	PageSize   int64
+}
+
+// IteratorFunc fetches a page for a PageIterator, pageNumber and pageSize are nil for the first page
+type IteratorFunc func(ctx context.Context, pageNumber *int32, pageSize *int32) (*IteratorPage, error)
+
+// PageIterator iterates over the items of a paginated list fetching the pages lazily
+//
+//	for it.Next() {
+//		item := it.Item()
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type PageIterator struct {
+	ctx   context.Context
+	fetch IteratorFunc
+
+	items      []interface{}
+	index      int
+	started    bool
+	nextPage   int32
+	pageSize   int32
+	totalPages int32
+	err        error
+}
+
+// NewPageIterator returns a PageIterator fetching pages with fetch, no page is fetched until Next is called
+func NewPageIterator(ctx context.Context, fetch IteratorFunc) *PageIterator {
+	return &PageIterator{
+		ctx:   ctx,
+		fetch: fetch,
+		index: -1,
+	}
+}
+
+// Next advances to the next item fetching the next page if required, returns false when there are no more
+// items or an error happened
+func (it *PageIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+
+	it.index++
+	for it.index >= len(it.items) {
+		if it.started && it.nextPage >= it.totalPages {
+			return false
+		}
+		if !it.fetchPage() {
+			return false
+		}
+	}
+
+	return true
+}
+
+// fetchPage replaces the current items with the next page
+func (it *PageIterator) fetchPage() bool {
+	if err := it.ctx.Err(); err != nil {
+		it.err = err
+		return false
+	}
+
+	var page *IteratorPage
+	var err error
+	if !it.started {
+		// first page, let ECX choose the page size
+		page, err = it.fetch(it.ctx, nil, nil)
+	} else {
+		pageNumber, pageSize := it.nextPage, it.pageSize
+		page, err = it.fetch(it.ctx, &pageNumber, &pageSize)
+	}
+	if err != nil {
+		it.err = &PageError{Page: it.nextPage, Err: err}
+		return false
+	}
+
+	if !it.started {
+		it.started = true
+		if page != nil && page.PageSize > 0 {
+			it.pageSize = int32(page.PageSize)
+			it.totalPages = int32((page.TotalCount + page.PageSize - 1) / page.PageSize)
+		}
+	}
+	it.nextPage++
+
+	if page == nil || len(page.Items) == 0 {
+		// nothing else to read, don't trust the total count any more
+		it.totalPages = it.nextPage
+		it.items = nil
+		it.index = 0
+		return false
+	}
+
+	it.items = page.Items
+	it.index = 0
+	return true
+}
+
+// Item returns the current item
+func (it *PageIterator) Item() interface{} {
+	if it.index < 0 || it.index >= len(it.items) {
+		return nil
+	}
+	return it.items[it.index]
+}
+
+// Err returns the error that stopped the iteration, if any
+func (it *PageIterator) Err() error {
+	return it.err
+}
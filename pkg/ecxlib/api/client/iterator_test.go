@@ -0,0 +1,80 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// pagedFetch serves total items in pages of size 10, failing on page failPage (-1 never fails)
+func pagedFetch(total int, failPage int32, fetched *[]int32) IteratorFunc {
+	return func(ctx context.Context, pageNumber *int32, pageSize *int32) (*IteratorPage, error) {
+		page := int32(0)
+		if pageNumber != nil {
+			page = *pageNumber
+		}
+		*fetched = append(*fetched, page)
+		if page == failPage {
+			return nil, errors.New("service unavailable")
+		}
+
+		var items []interface{}
+		for i := int(page) * 10; i < total && i < int(page+1)*10; i++ {
+			items = append(items, i)
+		}
+		return &IteratorPage{Items: items, TotalCount: int64(total), PageSize: 10}, nil
+	}
+}
+
+func TestPageIteratorWalksAllItems(t *testing.T) {
+	var fetched []int32
+	it := NewPageIterator(context.Background(), pagedFetch(25, -1, &fetched))
+
+	count := 0
+	for it.Next() {
+		if it.Item().(int) != count {
+			t.Errorf("Expected item %d, received %v", count, it.Item())
+		}
+		count++
+	}
+	if it.Err() != nil {
+		t.Fatalf("Unexpected error iterating: %s", it.Err())
+	}
+	if count != 25 {
+		t.Errorf("Expected 25 items, received %d", count)
+	}
+	if len(fetched) != 3 {
+		t.Errorf("Expected 3 pages fetched, received %v", fetched)
+	}
+}
+
+func TestPageIteratorFetchesLazily(t *testing.T) {
+	var fetched []int32
+	it := NewPageIterator(context.Background(), pagedFetch(100, -1, &fetched))
+
+	if len(fetched) != 0 {
+		t.Errorf("Expected no page fetched before Next, received %v", fetched)
+	}
+	for i := 0; i < 11 && it.Next(); i++ {
+	}
+	if len(fetched) != 2 {
+		t.Errorf("Expected 2 pages fetched for 11 items, received %v", fetched)
+	}
+}
+
+func TestPageIteratorError(t *testing.T) {
+	var fetched []int32
+	it := NewPageIterator(context.Background(), pagedFetch(30, 1, &fetched))
+
+	count := 0
+	for it.Next() {
+		count++
+	}
+	var pageErr *PageError
+	if !errors.As(it.Err(), &pageErr) || pageErr.Page != 1 {
+		t.Fatalf("Expected PageError for page 1, received %v", it.Err())
+	}
+	if count != 10 {
+		t.Errorf("Expected items of the first page, received %d", count)
+	}
+}
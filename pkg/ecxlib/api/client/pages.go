@@ -0,0 +1,95 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+// DefaultPageConcurrency pages fetched at the same time when walking paginated lists
+const DefaultPageConcurrency = 4
+
+// PageFunc fetches page pageNumber of size pageSize
+type PageFunc func(ctx context.Context, pageNumber int32, pageSize int32) (interface{}, error)
+
+// PageCollector receives every page returned by a PageFunc, in page order
+type PageCollector func(page interface{})
+
+// PageError error fetching a page, all the pages before Page were already collected
+type PageError struct {
+	Page int32
+	Err  error
+}
+
+// Error returns the failed page along with the original error
+func (e *PageError) Error() string {
+	return fmt.Sprintf("error fetching page %d: %s", e.Page, e.Err.Error())
+}
+
+// Unwrap returns the original error
+func (e *PageError) Unwrap() error {
+	return e.Err
+}
+
+// WalkPages fetches the remaining pages of a list whose first page (page 0) was already fetched, up to concurrency
+// pages at the same time. Pages are handed to collect in order, on error the walk stops returning a PageError and
+// collect has received every page before the failed one.
+func WalkPages(ctx context.Context, concurrency int, totalCount int64, pageSize int64, fetch PageFunc, collect PageCollector) error {
+	if pageSize <= 0 || totalCount <= pageSize {
+		return nil
+	}
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	// Start iterating from page 1 as we have "page 0" (yeah...swagger implementation of first page)
+	totalPages := int(math.Ceil(float64(totalCount) / float64(pageSize)))
+	psize := int32(pageSize)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type pageResult struct {
+		page interface{}
+		err  error
+	}
+
+	// one buffered channel per page keeps the order and never blocks workers once we stop reading
+	results := make([]chan pageResult, totalPages)
+	for i := range results {
+		results[i] = make(chan pageResult, 1)
+	}
+
+	go func() {
+		sem := make(chan struct{}, concurrency)
+		for p := 1; p < totalPages; p++ {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				results[p] <- pageResult{err: ctx.Err()}
+				continue
+			}
+
+			go func(p int) {
+				defer func() { <-sem }()
+				page, err := fetch(ctx, int32(p), psize)
+				results[p] <- pageResult{page: page, err: err}
+			}(p)
+		}
+	}()
+
+	for p := 1; p < totalPages; p++ {
+		result := <-results[p]
+		if result.err != nil {
+			return &PageError{Page: int32(p), Err: result.err}
+		}
+		collect(result.page)
+	}
+
+	return nil
+}
+
+// WalkPages walks the remaining pages of a list using the client PageConcurrency
+func (ec *EquinixAPIClient) WalkPages(ctx context.Context, totalCount int64, pageSize int64, fetch PageFunc, collect PageCollector) error {
+	return WalkPages(ctx, ec.PageConcurrency, totalCount, pageSize, fetch, collect)
+}
@@ -0,0 +1,69 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWalkPagesKeepsOrder(t *testing.T) {
+	var running, maxRunning int32
+	fetch := func(ctx context.Context, pageNumber int32, pageSize int32) (interface{}, error) {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			m := atomic.LoadInt32(&maxRunning)
+			if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+				break
+			}
+		}
+		// later pages answer first
+		time.Sleep(time.Duration(10-pageNumber) * time.Millisecond)
+		return pageNumber, nil
+	}
+
+	var pages []int32
+	err := WalkPages(context.Background(), 3, 95, 10, fetch, func(page interface{}) {
+		pages = append(pages, page.(int32))
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error walking pages: %s", err)
+	}
+
+	if len(pages) != 9 {
+		t.Fatalf("Expected 9 pages after page 0, received %d", len(pages))
+	}
+	for i, p := range pages {
+		if p != int32(i+1) {
+			t.Errorf("Expected page %d at position %d, received %d", i+1, i, p)
+		}
+	}
+	if maxRunning > 3 {
+		t.Errorf("Expected at most 3 concurrent pages, received %d", maxRunning)
+	}
+}
+
+func TestWalkPagesPartialResults(t *testing.T) {
+	failure := errors.New("service unavailable")
+	fetch := func(ctx context.Context, pageNumber int32, pageSize int32) (interface{}, error) {
+		if pageNumber == 4 {
+			return nil, failure
+		}
+		return pageNumber, nil
+	}
+
+	var pages []int32
+	err := WalkPages(context.Background(), 2, 100, 10, fetch, func(page interface{}) {
+		pages = append(pages, page.(int32))
+	})
+
+	var pageErr *PageError
+	if !errors.As(err, &pageErr) || pageErr.Page != 4 || !errors.Is(err, failure) {
+		t.Fatalf("Expected PageError for page 4, received %v", err)
+	}
+	if len(pages) != 3 {
+		t.Errorf("Expected pages 1-3 to be collected, received %v", pages)
+	}
+}
@@ -0,0 +1,43 @@
+package client
+
+import (
+	"context"
+	"sync"
+)
+
+// ParallelFunc runs task i of a Parallel call
+type ParallelFunc func(ctx context.Context, i int) error
+
+// Parallel calls fn for every task from 0 to n-1, up to concurrency at the same time, and returns their errors in
+// task order. A failed task doesn't stop the others, once ctx is done the tasks not started yet fail with ctx.Err().
+func Parallel(ctx context.Context, concurrency int, n int, fn ParallelFunc) []error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	errs := make([]error, n)
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		if err := ctx.Err(); err != nil {
+			errs[i] = err
+			continue
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			errs[i] = ctx.Err()
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			errs[i] = fn(ctx, i)
+		}(i)
+	}
+	wg.Wait()
+
+	return errs
+}
@@ -0,0 +1,52 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParallel(t *testing.T) {
+	errOdd := errors.New("odd")
+	var running, maxRunning int32
+	errs := Parallel(context.Background(), 2, 6, func(ctx context.Context, i int) error {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			max := atomic.LoadInt32(&maxRunning)
+			if n <= max || atomic.CompareAndSwapInt32(&maxRunning, max, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		if i%2 == 1 {
+			return errOdd
+		}
+		return nil
+	})
+
+	if maxRunning > 2 {
+		t.Errorf("Expected at most 2 tasks at the same time, received %d", maxRunning)
+	}
+	for i, err := range errs {
+		if (i%2 == 1) != (err == errOdd) {
+			t.Errorf("Task %d: unexpected error %v", i, err)
+		}
+	}
+}
+
+func TestParallelCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var calls int32
+	errs := Parallel(ctx, 1, 3, func(ctx context.Context, i int) error {
+		atomic.AddInt32(&calls, 1)
+		return nil
+	})
+	if calls != 0 || errs[0] != context.Canceled || errs[2] != context.Canceled {
+		t.Errorf("Expected tasks not to start once cancelled, received %d calls %v", calls, errs)
+	}
+}
@@ -0,0 +1,75 @@
+package client
+
+import (
+	"context"
+	"time"
+)
+
+// PollPolicy defines the wait between polls of the WaitFor calls, zero fields take the default values
+type PollPolicy struct {
+	// Interval first wait between polls, multiplied by Multiplier after every poll up to MaxInterval
+	Interval    time.Duration
+	MaxInterval time.Duration
+	Multiplier  float64
+}
+
+// DefaultPollPolicy polls every 5 seconds, backing off up to 30 seconds
+func DefaultPollPolicy() *PollPolicy {
+	return &PollPolicy{
+		Interval:    5 * time.Second,
+		MaxInterval: 30 * time.Second,
+		Multiplier:  1.5,
+	}
+}
+
+// withDefaults returns p with the zero fields (all of them if p is nil) set from DefaultPollPolicy
+func (p *PollPolicy) withDefaults() PollPolicy {
+	policy := *DefaultPollPolicy()
+	if p == nil {
+		return policy
+	}
+	if p.Interval > 0 {
+		policy.Interval = p.Interval
+	}
+	if p.MaxInterval > 0 {
+		policy.MaxInterval = p.MaxInterval
+	}
+	if p.Multiplier > 0 {
+		policy.Multiplier = p.Multiplier
+	}
+	return policy
+}
+
+// PollFunc polls the state of a resource once, returning done when it's the awaited one
+type PollFunc func(ctx context.Context) (done bool, err error)
+
+// Poll calls poll until it's done or fails, waiting between calls as defined by the client PollPolicy. Errors
+// returned by poll once ctx is done are replaced by ctx.Err(), a poll may return done along with an error to stop
+// polling on failed states.
+func (c *EquinixAPIClient) Poll(ctx context.Context, poll PollFunc) error {
+	policy := c.PollPolicy.withDefaults()
+	interval := policy.Interval
+	for {
+		done, err := poll(ctx)
+		if done {
+			return err
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+
+		interval = time.Duration(float64(interval) * policy.Multiplier)
+		if interval > policy.MaxInterval {
+			interval = policy.MaxInterval
+		}
+	}
+}
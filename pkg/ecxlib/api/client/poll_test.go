@@ -0,0 +1,55 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPollPolicyDefaults(t *testing.T) {
+	var nilPolicy *PollPolicy
+	if policy := nilPolicy.withDefaults(); policy != *DefaultPollPolicy() {
+		t.Errorf("Expected the default policy, received %+v", policy)
+	}
+
+	policy := (&PollPolicy{Interval: time.Millisecond}).withDefaults()
+	if policy.Interval != time.Millisecond || policy.MaxInterval != 30*time.Second || policy.Multiplier != 1.5 {
+		t.Errorf("Expected zero fields to take the defaults, received %+v", policy)
+	}
+}
+
+func TestPoll(t *testing.T) {
+	ec := &EquinixAPIClient{PollPolicy: &PollPolicy{Interval: time.Millisecond}}
+	errFailed := errors.New("failed")
+
+	tests := []struct {
+		doneAt int
+		err    error
+	}{
+		{3, nil},
+		{2, errFailed},
+	}
+	for _, test := range tests {
+		polls := 0
+		err := ec.Poll(context.Background(), func(ctx context.Context) (bool, error) {
+			polls++
+			if polls == test.doneAt {
+				return true, test.err
+			}
+			return false, nil
+		})
+		if err != test.err || polls != test.doneAt {
+			t.Errorf("Expected %v after %d polls, received %v after %d", test.err, test.doneAt, err, polls)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := ec.Poll(ctx, func(ctx context.Context) (bool, error) {
+		return false, ctx.Err()
+	})
+	if err != context.DeadlineExceeded {
+		t.Errorf("Expected context.DeadlineExceeded, received %v", err)
+	}
+}
@@ -1,7 +1,11 @@
 package client
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -14,147 +18,285 @@ type ECXAPIResponse interface {
 	Count() int
 }
 
+// Collection ECXAPIResponse over items of type T, embedded by the typed responses
+type Collection[T any] struct {
+	Items []T
+}
+
+// AppendItems appends items to internal Items, items not of type T are a programming error and panic
+func (c *Collection[T]) AppendItems(items []interface{}) {
+	c.Items = append(c.Items, c.typed(items)...)
+}
+
+// SetItems replaces internal Items with items, items not of type T are a programming error and panic
+func (c *Collection[T]) SetItems(items []interface{}) {
+	c.Items = c.typed(items)
+}
+
+// GetItems retrieves all Items as a slice of interface
+func (c *Collection[T]) GetItems() []interface{} {
+	items := make([]interface{}, len(c.Items))
+	for i, item := range c.Items {
+		items[i] = item
+	}
+	return items
+}
+
+// FilterItems applies specific filters to items and updates internal items
+func (c *Collection[T]) FilterItems(filters map[string]string) {
+	ResponseFilter(c, filters)
+}
+
+// Count return total count of items
+func (c *Collection[T]) Count() int {
+	return len(c.Items)
+}
+
+// typed converts items to T, nil items become the zero T
+func (c *Collection[T]) typed(items []interface{}) []T {
+	typed := make([]T, 0, len(items))
+	for _, item := range items {
+		t, ok := item.(T)
+		if !ok && item != nil {
+			panic(fmt.Sprintf("client: %T item added to a collection of %T", item, c.Items))
+		}
+		typed = append(typed, t)
+	}
+	return typed
+}
+
 type ECXAPIPayload interface {
 	Get() []interface{}
 	Count() int64
 }
 
-// ResponseFilter applies a set of map string filters to a Response and sets the new value to Response items
+// ResponseFilter applies a set of map string filters to a Response and sets the new value to Response items, items
+// are kept when any filter matches. Keys are json field paths (see ParseFilter), string values match fields
+// containing them and any other kind must be equal.
 func ResponseFilter(response ECXAPIResponse, filters map[string]string) {
-	// TODO: implement nested filtering
-	// Obtain interface of slice
-	r := response.GetItems()
-	//make a zero lenght copy to append items later
-	newitems := r[:0]
-	for _, item := range r {
-		t := reflect.TypeOf(item)
-		// if type is a pointer then assign to the element
-		if t.Kind() == reflect.Ptr {
-			t = t.Elem()
+	var filter Filter
+	for key, value := range filters {
+		var cond Filter = &conditionFilter{field: key, op: containsOp, value: value}
+		if filter == nil {
+			filter = cond
+		} else {
+			filter = &orFilter{filter, cond}
 		}
-		// we're accepting only structs
-		if t.Kind() != reflect.Struct {
-			continue
-		}
-
-		// iterate over struct fields to check tag annotations for each one
-		filtermatch := false
-		for i := 0; i < t.NumField(); i++ {
-			if !filtermatch {
-				f := t.Field(i)
-				// skip unexported fields (PkgPath is the package pat that qualifies lower case - unexported - fields)
-				if f.PkgPath != "" {
-					continue
-				}
-				// try to obtain tags for json annotation will skip any other annotation
-				tag := f.Tag.Get("json")
-				if tag != "" && !filtermatch {
-					for key, value := range filters {
-						// parsetag options, tagName is the actual name optionos not used here
-						tagName, _ := parseTag(tag)
-						// if the actual field tag equals the key we are looking for
-						if tagName == key {
-							// obtain the struct by reflection
-							r := reflect.ValueOf(item)
-							// obtain the struct field value by reflection to compare (yep..inneficient and costly)
-							valueField := reflect.Indirect(r).FieldByName(f.Name)
-							// safe switch value, we need to verify each one...and cast the required ones
-							switch valueField.Kind() {
-							case reflect.String:
-								//we are moving from a strict filter to a contains filter
-								//if valueField.String() == value {
-								if strings.Contains(valueField.String(), value) {
-									newitems = append(newitems, item)
-									filtermatch = true
-								}
-
-							case reflect.Int64:
-								intv, err := strconv.ParseInt(value, 10, 32)
-								if err != nil {
-									// just continue, we werent able to parse the int of value
-									continue
-								}
-								if valueField.Int() == intv {
-									newitems = append(newitems, item)
-									filtermatch = true
-								}
-
-							}
-
-						}
-
-					}
-				}
-			}
+	}
+	if filter == nil {
+		response.SetItems(response.GetItems()[:0])
+		return
+	}
+	ResponseFilterExpression(response, filter)
+}
+
+// ResponseSort sorts the response items by the value of field (a json field path, see ParseFilter), items without
+// the field go last. Numbers and dates held in strings are compared as such, the sort is stable.
+func ResponseSort(response ECXAPIResponse, field string, desc bool) {
+	items := response.GetItems()
+	path := strings.Split(field, ".")
+
+	type sortKey struct {
+		value reflect.Value
+		found bool
+	}
+	keys := make([]sortKey, len(items))
+	for i, item := range items {
+		if values := resolveField(reflect.ValueOf(item), path); len(values) > 0 {
+			keys[i] = sortKey{indirectValue(values[0]), true}
+		}
+	}
 
+	index := make([]int, len(items))
+	for i := range index {
+		index[i] = i
+	}
+	sort.SliceStable(index, func(i, j int) bool {
+		a, b := keys[index[i]], keys[index[j]]
+		if !a.found || !a.value.IsValid() {
+			return false
+		}
+		if !b.found || !b.value.IsValid() {
+			return true
+		}
+		c := compareValues(a.value, b.value)
+		if desc {
+			return c > 0
 		}
+		return c < 0
+	})
+
+	sorted := make([]interface{}, len(items))
+	for i, idx := range index {
+		sorted[i] = items[idx]
 	}
-	// set filtered items to response
-	response.SetItems(newitems)
+	response.SetItems(sorted)
 }
 
-/**
-type PageResponse struct {
-	Response ConnectionsResponse
-	Error    error
+// indirectValue dereferences pointers and interfaces, returns an invalid value for nil
+func indirectValue(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// compareValues returns -1, 0 or 1 comparing two field values, values of different kinds are compared as strings
+func compareValues(a, b reflect.Value) int {
+	if af, ok := floatValue(a); ok {
+		if bf, ok := floatValue(b); ok {
+			return compareFloat(af, bf)
+		}
+	}
+	if at, ok := timeValue(a); ok {
+		if bt, ok := timeValue(b); ok {
+			return compareTime(at, bt)
+		}
+	}
+	if a.Kind() == reflect.Bool && b.Kind() == reflect.Bool {
+		return compareFloat(boolFloat(a.Bool()), boolFloat(b.Bool()))
+	}
+
+	as, bs := formatValue(a), formatValue(b)
+	if af, err := strconv.ParseFloat(as, 64); err == nil {
+		if bf, err := strconv.ParseFloat(bs, 64); err == nil {
+			return compareFloat(af, bf)
+		}
+	}
+	if at, ok := parseFilterTime(as); ok {
+		if bt, ok := parseFilterTime(bs); ok {
+			return compareTime(at, bt)
+		}
+	}
+	return strings.Compare(as, bs)
 }
 
-// PageWalker traverses paging (traversing pagination)
-func (m *ECXConnectionsAPI) PageWalker(metro *string) (*ConnectionsResponse, error) {
-	//c := make(chan ConnectionsResult)
-	//defer close(c)
+func floatValue(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
 
-	connectionsList, err := m.GetBuyerConnections(nil, nil, metro)
-	if err != nil {
-		return nil, err
+func boolFloat(b bool) float64 {
+	if b {
+		return 1
 	}
+	return 0
+}
 
-	totalCount := connectionsList.PageTotalCount
-	pageSize := connectionsList.PageSize
-	totalPages := int64(math.Ceil(float64(totalCount) / float64(pageSize)))
-	// channels
+// ProjectedItem fields of an item selected by ResponseFields, marshals to a JSON object keeping the fields order
+type ProjectedItem struct {
+	Fields []string
+	Values []interface{}
+}
 
-	if pageSize > 0 && totalCount > 0 {
-		// Start iterating from page 1 as we have "page 0" (yeah...swagger implementation of first page)
-		c := make(chan PageResponse)
-		var wg sync.WaitGroup
-		var errorProc error
+// Get returns the value of field, nil if the field wasn't projected or the item didn't have it
+func (p *ProjectedItem) Get(field string) interface{} {
+	for i, f := range p.Fields {
+		if f == field {
+			return p.Values[i]
+		}
+	}
+	return nil
+}
+
+// MarshalJSON marshals the fields in order
+func (p *ProjectedItem) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, field := range p.Fields {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(field)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(p.Values[i])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
 
-		psize := int32(pageSize)
-		for p := 1; p <= int(totalPages-1); p++ {
-			next := int32(p)
-			wg.Add(1)
-			go func() {
-				defer wg.Done()
-				req, err := m.GetBuyerConnections(&next, &psize, metro)
-				res := PageResponse{
-					Response: *req,
-					Error:    err,
-				}
-				c <- res
-			}()
+// ResponseFields projects the response items to the given fields (json field paths, see ParseFilter). Paths going
+// through lists return the list of values found, fields missing in an item are nil.
+func ResponseFields(response ECXAPIResponse, fields []string) []*ProjectedItem {
+	items := response.GetItems()
+	projected := make([]*ProjectedItem, len(items))
+	for i, item := range items {
+		p := &ProjectedItem{Fields: fields, Values: make([]interface{}, len(fields))}
+		for j, field := range fields {
+			p.Values[j] = projectField(reflect.ValueOf(item), strings.Split(field, "."))
 		}
+		projected[i] = p
+	}
+	return projected
+}
 
-		go func() {
-			wg.Wait()
-			close(c)
-		}()
+// projectField returns the value at path, a slice of values when the path goes through lists
+func projectField(v reflect.Value, path []string) interface{} {
+	values := resolvePath(v, path, false)
+	if len(values) == 0 {
+		return nil
+	}
+	if len(values) == 1 && !throughList(v, path) {
+		return interfaceValue(values[0])
+	}
+	list := make([]interface{}, len(values))
+	for i, value := range values {
+		list[i] = interfaceValue(value)
+	}
+	return list
+}
 
-		for resp := range c {
-			if resp.Error != nil {
-				errorProc = resp.Error
-				break
-			} else {
-				connectionsList.AppendItems(resp.Response.GetItems())
+// throughList returns true if path traverses a list (other than indexing it) before its last segment
+func throughList(v reflect.Value, path []string) bool {
+	t := v.Type()
+	for _, segment := range path {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+			if _, err := strconv.Atoi(segment); err == nil {
+				t = t.Elem()
+				continue
 			}
+			return true
 		}
-
-		if errorProc != nil {
-			return nil, errorProc
+		switch t.Kind() {
+		case reflect.Struct:
+			field, ok := lookupField(reflect.New(t).Elem(), segment)
+			if !ok {
+				return false
+			}
+			t = field.Type()
+		case reflect.Map:
+			t = t.Elem()
+		default:
+			return false
 		}
 	}
+	return false
+}
 
-	return connectionsList, nil
-
+func interfaceValue(v reflect.Value) interface{} {
+	if !v.IsValid() || !v.CanInterface() {
+		return nil
+	}
+	return v.Interface()
 }
-**/
@@ -87,3 +87,52 @@ func TestResponseFilter(t *testing.T) {
 	}
 
 }
+
+func TestResponseSort(t *testing.T) {
+	resp := ECXAPIResponseMock{}
+	resp.SetItems([]interface{}{
+		&models.GETConnectionByUUIDResponse{Name: "B", Speed: 500, CreatedDate: "2018-10-29T16:40:55.470Z"},
+		&models.GETConnectionByUUIDResponse{Name: "C", Speed: 50, CreatedDate: "2019-01-02T10:00:00.000Z"},
+		&models.GETConnectionByUUIDResponse{Name: "A", Speed: 1000, CreatedDate: "2017-05-01T10:00:00.000Z"},
+	})
+
+	names := func() string {
+		s := ""
+		for _, item := range resp.Items {
+			s += item.(*models.GETConnectionByUUIDResponse).Name
+		}
+		return s
+	}
+
+	ResponseSort(&resp, "speed", false)
+	if names() != "CBA" {
+		t.Errorf("Expected CBA sorting by speed, received %s", names())
+	}
+	ResponseSort(&resp, "createdDate", true)
+	if names() != "CBA" {
+		t.Errorf("Expected CBA sorting by createdDate desc, received %s", names())
+	}
+	ResponseSort(&resp, "name", false)
+	if names() != "ABC" {
+		t.Errorf("Expected ABC sorting by name, received %s", names())
+	}
+}
+
+func TestResponseFields(t *testing.T) {
+	resp := ECXAPIResponseMock{}
+	connAPIResponse := models.GETConnectionByUUIDResponse{}
+	if err := json.Unmarshal([]byte(responseJSON), &connAPIResponse); err != nil {
+		t.Fatalf("Can't unmarshal test JSON into connection struct")
+	}
+	resp.SetItems([]interface{}{&connAPIResponse})
+
+	projected := ResponseFields(&resp, []string{"uuid", "speed", "metadata.integration_id", "notifications", "missing"})
+	out, err := json.Marshal(projected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `[{"uuid":"07c8a274-4e80-4662-8cb9-636b8b00eb26","speed":50,"metadata.integration_id":"AWS-DirectConnect-01","notifications":["jxoir@github.com"],"missing":null}]`
+	if string(out) != expected {
+		t.Errorf("Expected %s, received %s", expected, out)
+	}
+}
@@ -0,0 +1,126 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-openapi/runtime"
+)
+
+// RetryPolicy defines how API calls failing with transient errors are retried
+type RetryPolicy struct {
+	// MaxAttempts total number of attempts including the first one, 1 or less disables retries
+	MaxAttempts int
+	// InitialBackoff wait before the first retry
+	InitialBackoff time.Duration
+	// MaxBackoff upper bound for the wait between attempts
+	MaxBackoff time.Duration
+	// Multiplier applied to the backoff after each attempt
+	Multiplier float64
+	// Jitter fraction (0-1) of the backoff randomly subtracted to spread retries from concurrent clients
+	Jitter float64
+	// RetryableStatusCodes HTTP status codes considered transient
+	RetryableStatusCodes []int
+	// RetryNonIdempotent allows retrying POST and PATCH operations, which may duplicate resources
+	RetryNonIdempotent bool
+}
+
+// DefaultRetryPolicy retries idempotent calls up to 3 times on 429 and 5xx gateway errors
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:          3,
+		InitialBackoff:       500 * time.Millisecond,
+		MaxBackoff:           10 * time.Second,
+		Multiplier:           2,
+		Jitter:               0.2,
+		RetryableStatusCodes: []int{http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout},
+	}
+}
+
+// idempotent returns true for operations that can be safely sent more than once
+func idempotent(op *runtime.ClientOperation) bool {
+	switch op.ID {
+	case accessTokenOperationID, refreshAccessTokenOperationID:
+		return true
+	}
+	switch op.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// shouldRetry returns true if the failed attempt number attempt must be retried
+func (p *RetryPolicy) shouldRetry(ctx context.Context, op *runtime.ClientOperation, attempt int, statusCode int, err error) bool {
+	if p == nil || attempt >= p.MaxAttempts || ctx.Err() != nil {
+		return false
+	}
+
+	// no response, only network errors are transient
+	if statusCode == 0 {
+		var netErr net.Error
+		return errors.As(err, &netErr) && (idempotent(op) || p.RetryNonIdempotent)
+	}
+
+	for _, code := range p.RetryableStatusCodes {
+		if code == statusCode {
+			// a throttled request was never processed, it's safe to send it again whatever the method
+			return statusCode == http.StatusTooManyRequests || idempotent(op) || p.RetryNonIdempotent
+		}
+	}
+	return false
+}
+
+// backoff returns the wait before the retry following attempt, a Retry-After from ECX takes precedence
+func (p *RetryPolicy) backoff(attempt int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		return retryAfter
+	}
+
+	wait := float64(p.InitialBackoff) * math.Pow(p.Multiplier, float64(attempt-1))
+	if p.MaxBackoff > 0 && wait > float64(p.MaxBackoff) {
+		wait = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		wait -= wait * p.Jitter * rand.Float64()
+	}
+	return time.Duration(wait)
+}
+
+// parseRetryAfter parses a Retry-After header value in seconds or HTTP date format
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(date); wait > 0 {
+			return wait
+		}
+	}
+	return 0
+}
+
+// sleep waits for d or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,115 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	apiconnections "github.com/jxoir/go-ecxfabric/buyer/client/connections"
+	"github.com/jxoir/go-ecxfabric/buyer/models"
+)
+
+// newRetryTestServer mocks ECX answering every API call with status until failures requests were received
+func newRetryTestServer(status int, failures int32, calls *int32) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/oauth2/v1/token", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token":"t1","token_timeout":"3600"}`)
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if atomic.AddInt32(calls, 1) <= failures {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(status)
+			fmt.Fprint(w, `{"errorCode":"IC-LAYER2-500","errorMessage":"Service unavailable"}`)
+			return
+		}
+		fmt.Fprint(w, `[]`)
+	})
+	return httptest.NewTLSServer(mux)
+}
+
+func newRetryTestClient(server *httptest.Server) *EquinixAPIClient {
+	ec := newTokenTestClient(server)
+	ec.RetryPolicy.InitialBackoff = time.Millisecond
+	return ec
+}
+
+func TestRetryIdempotentCall(t *testing.T) {
+	var calls int32
+	server := newRetryTestServer(http.StatusServiceUnavailable, 2, &calls)
+	defer server.Close()
+
+	ec := newRetryTestClient(server)
+	token, _ := ec.GetToken()
+	if _, _, err := ec.Buyer.Metros.GetMetrosUsingGET(nil, token); err != nil {
+		t.Fatalf("Expected call to succeed after retries, received %s", err)
+	}
+	if calls != 3 {
+		t.Errorf("Expected 3 attempts, received %d", calls)
+	}
+}
+
+func TestRetryGivesUpAfterMaxAttempts(t *testing.T) {
+	var calls int32
+	server := newRetryTestServer(http.StatusBadGateway, 10, &calls)
+	defer server.Close()
+
+	ec := newRetryTestClient(server)
+	token, _ := ec.GetToken()
+	_, _, err := ec.Buyer.Metros.GetMetrosUsingGET(nil, token)
+	if !IsStatus(err, http.StatusBadGateway) {
+		t.Fatalf("Expected 502 APIError, received %v", err)
+	}
+	if calls != int32(ec.RetryPolicy.MaxAttempts) {
+		t.Errorf("Expected %d attempts, received %d", ec.RetryPolicy.MaxAttempts, calls)
+	}
+}
+
+func TestNoRetryOnNonIdempotentCall(t *testing.T) {
+	var calls int32
+	server := newRetryTestServer(http.StatusServiceUnavailable, 1, &calls)
+	defer server.Close()
+
+	ec := newRetryTestClient(server)
+	token, _ := ec.GetToken()
+	params := apiconnections.NewCreateConnectionUsingPOSTParams()
+	params.Request = &models.PostConnectionRequest{PrimaryName: "EQUINIX_TEST"}
+	if _, err := ec.Buyer.Connections.CreateConnectionUsingPOST(params, token); err == nil {
+		t.Fatalf("Expected create connection to fail without retries")
+	}
+	if calls != 1 {
+		t.Errorf("Expected 1 attempt, received %d", calls)
+	}
+}
+
+func TestRetryThrottledNonIdempotentCall(t *testing.T) {
+	var calls int32
+	server := newRetryTestServer(http.StatusTooManyRequests, 1, &calls)
+	defer server.Close()
+
+	ec := newRetryTestClient(server)
+	token, _ := ec.GetToken()
+	params := apiconnections.NewCreateConnectionUsingPOSTParams()
+	params.Request = &models.PostConnectionRequest{PrimaryName: "EQUINIX_TEST"}
+	ec.Buyer.Connections.CreateConnectionUsingPOST(params, token)
+	if calls != 2 {
+		t.Errorf("Expected throttled request to be sent again, received %d attempts", calls)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	if d := parseRetryAfter("3"); d != 3*time.Second {
+		t.Errorf("Expected 3s, received %s", d)
+	}
+	date := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	if d := parseRetryAfter(date); d <= 0 || d > time.Minute {
+		t.Errorf("Expected up to 1m, received %s", d)
+	}
+	if d := parseRetryAfter("soon"); d != 0 {
+		t.Errorf("Expected 0 for invalid value, received %s", d)
+	}
+}
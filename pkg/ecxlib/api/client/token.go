@@ -0,0 +1,221 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/go-openapi/runtime"
+	httptransport "github.com/go-openapi/runtime/client"
+	"github.com/go-openapi/strfmt"
+	"github.com/jxoir/go-ecxfabric/buyer/client/access_token"
+	"github.com/jxoir/go-ecxfabric/buyer/models"
+)
+
+const (
+	// accessTokenOperationID swagger operation id used to obtain a new token
+	accessTokenOperationID = "getAccessToken"
+	// refreshAccessTokenOperationID operation id used to refresh an existing token
+	refreshAccessTokenOperationID = "refreshAccessToken"
+)
+
+// tokenExpiryMargin renews the token a bit before ECX expires it so in-flight requests don't fail
+var tokenExpiryMargin = 60 * time.Second
+
+// refreshAccessTokenRequest body for the oauth2 refresh call, the generated OAuthRequest model lacks refresh_token
+type refreshAccessTokenRequest struct {
+	ClientID     string `json:"client_id,omitempty"`
+	ClientSecret string `json:"client_secret,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
+
+// refreshAccessTokenParams writes refreshAccessTokenRequest into a swagger request
+type refreshAccessTokenParams struct {
+	Request *refreshAccessTokenRequest
+}
+
+// WriteToRequest writes these params to a swagger request
+func (o *refreshAccessTokenParams) WriteToRequest(r runtime.ClientRequest, reg strfmt.Registry) error {
+	return r.SetBodyParam(o.Request)
+}
+
+// setToken stores the bearer token along with its expiry and refresh information, tokenMu must be held
+func (ec *EquinixAPIClient) setToken(payload *models.OAuthResponse) {
+	now := time.Now()
+
+	ec.accessToken = payload.AccessToken
+	ec.apiToken = httptransport.BearerToken(payload.AccessToken)
+	ec.tokenExpiresAt = time.Time{}
+	if payload.TokenTimeout > 0 {
+		ec.tokenExpiresAt = now.Add(time.Duration(payload.TokenTimeout) * time.Second)
+	}
+
+	ec.refreshToken = payload.RefreshToken
+	ec.refreshTokenExpiresAt = time.Time{}
+	if timeout, err := strconv.ParseInt(payload.RefreshTokenTimeout, 10, 64); err == nil && timeout > 0 {
+		ec.refreshTokenExpiresAt = now.Add(time.Duration(timeout) * time.Second)
+	}
+}
+
+// tokenExpired returns true if the token will expire within tokenExpiryMargin, tokenMu must be held
+func (ec *EquinixAPIClient) tokenExpired() bool {
+	if ec.tokenExpiresAt.IsZero() {
+		return false
+	}
+	return time.Now().Add(tokenExpiryMargin).After(ec.tokenExpiresAt)
+}
+
+// canRefresh returns true if there is a refresh token still valid, tokenMu must be held
+func (ec *EquinixAPIClient) canRefresh() bool {
+	if ec.refreshToken == "" {
+		return false
+	}
+	return ec.refreshTokenExpiresAt.IsZero() || time.Now().Before(ec.refreshTokenExpiresAt)
+}
+
+// canReauthenticate returns true if a new token can be obtained, playground tokens are fixed
+func (ec *EquinixAPIClient) canReauthenticate() bool {
+	return ec.Params.PlaygroundToken == ""
+}
+
+// InvalidateToken discards the current token so the next call to GetToken authenticates again
+func (ec *EquinixAPIClient) InvalidateToken() {
+	ec.tokenMu.Lock()
+	defer ec.tokenMu.Unlock()
+
+	ec.apiToken = nil
+	ec.accessToken = ""
+	ec.tokenExpiresAt = time.Time{}
+	ec.deleteCachedToken()
+}
+
+// TokenExpiresAt returns the expiry time of the current token, zero if unknown
+func (ec *EquinixAPIClient) TokenExpiresAt() time.Time {
+	ec.tokenMu.Lock()
+	defer ec.tokenMu.Unlock()
+
+	return ec.tokenExpiresAt
+}
+
+// refresh exchanges the refresh token for a new access token, tokenMu must be held
+func (ec *EquinixAPIClient) refresh(ctx context.Context) error {
+	if ec.Debug {
+		log.Println("Refreshing token...")
+	}
+
+	params := &refreshAccessTokenParams{
+		Request: &refreshAccessTokenRequest{
+			ClientID:     ec.Params.AppID,
+			ClientSecret: ec.Params.AppSecret,
+			RefreshToken: ec.refreshToken,
+		},
+	}
+
+	result, err := ec.transport.Submit(&runtime.ClientOperation{
+		ID:                 refreshAccessTokenOperationID,
+		Method:             "POST",
+		PathPattern:        "/oauth2/v1/refreshaccesstoken",
+		ProducesMediaTypes: []string{"application/json"},
+		ConsumesMediaTypes: []string{"application/json"},
+		Schemes:            []string{"https"},
+		Params:             params,
+		Reader:             &access_token.GetAccessTokenReader{},
+		Context:            ctx,
+	})
+	if err != nil {
+		return err
+	}
+
+	accessToken, ok := result.(*access_token.GetAccessTokenOK)
+	if !ok || accessToken.Payload == nil || accessToken.Payload.AccessToken == "" {
+		return errors.New("refresh token response without access token")
+	}
+
+	refreshToken, refreshTokenExpiresAt := ec.refreshToken, ec.refreshTokenExpiresAt
+	ec.setToken(accessToken.Payload)
+	// keep the current refresh token if ECX doesn't rotate it
+	if ec.refreshToken == "" {
+		ec.refreshToken, ec.refreshTokenExpiresAt = refreshToken, refreshTokenExpiresAt
+	}
+	ec.storeCachedToken()
+
+	if ec.Debug {
+		log.Println("Token refreshed...")
+	}
+
+	return nil
+}
+
+// tokenCacheKey identifies the token of this client in the TokenCache
+func (ec *EquinixAPIClient) tokenCacheKey() string {
+	return ec.Params.Endpoint + "|" + ec.Params.AppID + "|" + ec.Params.UserName
+}
+
+// useTokenCache returns true if tokens should be read and written to the TokenCache
+func (ec *EquinixAPIClient) useTokenCache() bool {
+	return ec.Params.TokenCache != nil && ec.Params.PlaygroundToken == ""
+}
+
+// loadCachedToken restores the token from the TokenCache if there is one, tokenMu must be held
+func (ec *EquinixAPIClient) loadCachedToken() {
+	if !ec.useTokenCache() {
+		return
+	}
+
+	cached, err := ec.Params.TokenCache.Load(ec.tokenCacheKey())
+	if err != nil {
+		if ec.Debug {
+			log.Println("Failed to load cached token: " + err.Error())
+		}
+		return
+	}
+	if cached == nil || cached.AccessToken == "" {
+		return
+	}
+
+	if ec.Debug {
+		log.Println("Using cached token...")
+	}
+
+	ec.accessToken = cached.AccessToken
+	ec.apiToken = httptransport.BearerToken(cached.AccessToken)
+	ec.tokenExpiresAt = cached.ExpiresAt
+	ec.refreshToken = cached.RefreshToken
+	ec.refreshTokenExpiresAt = cached.RefreshTokenExpiresAt
+
+	// nothing left to reuse, drop it from the cache
+	if ec.tokenExpired() && !ec.canRefresh() {
+		ec.deleteCachedToken()
+	}
+}
+
+// storeCachedToken writes the current token to the TokenCache, tokenMu must be held
+func (ec *EquinixAPIClient) storeCachedToken() {
+	if !ec.useTokenCache() {
+		return
+	}
+
+	err := ec.Params.TokenCache.Store(ec.tokenCacheKey(), &CachedToken{
+		AccessToken:           ec.accessToken,
+		ExpiresAt:             ec.tokenExpiresAt,
+		RefreshToken:          ec.refreshToken,
+		RefreshTokenExpiresAt: ec.refreshTokenExpiresAt,
+	})
+	if err != nil && ec.Debug {
+		log.Println("Failed to store cached token: " + err.Error())
+	}
+}
+
+// deleteCachedToken removes the token from the TokenCache, tokenMu must be held
+func (ec *EquinixAPIClient) deleteCachedToken() {
+	if !ec.useTokenCache() {
+		return
+	}
+
+	err := ec.Params.TokenCache.Delete(ec.tokenCacheKey())
+	if err != nil && ec.Debug {
+		log.Println("Failed to delete cached token: " + err.Error())
+	}
+}
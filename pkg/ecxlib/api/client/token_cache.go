@@ -0,0 +1,109 @@
+package client
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// CachedToken token information persisted between client instances
+type CachedToken struct {
+	AccessToken           string    `json:"accessToken"`
+	ExpiresAt             time.Time `json:"expiresAt"`
+	RefreshToken          string    `json:"refreshToken,omitempty"`
+	RefreshTokenExpiresAt time.Time `json:"refreshTokenExpiresAt,omitempty"`
+}
+
+// TokenCache stores tokens by key so they can be reused by other client instances
+type TokenCache interface {
+	// Load returns the cached token for key, nil if there is none
+	Load(key string) (*CachedToken, error)
+	Store(key string, token *CachedToken) error
+	Delete(key string) error
+}
+
+// FileTokenCache TokenCache storing each token in its own file inside Dir
+type FileTokenCache struct {
+	Dir string
+}
+
+// NewFileTokenCache returns a FileTokenCache under dir, if dir is empty uses ecxctl/tokens under the user config dir
+func NewFileTokenCache(dir string) (*FileTokenCache, error) {
+	if dir == "" {
+		configDir, err := os.UserConfigDir()
+		if err != nil {
+			return nil, err
+		}
+		dir = filepath.Join(configDir, "ecxctl", "tokens")
+	}
+	return &FileTokenCache{Dir: dir}, nil
+}
+
+// path returns the file for key, keys are hashed as they contain user names
+func (c *FileTokenCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.Dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// Load reads the token for key, a missing file is not an error
+func (c *FileTokenCache) Load(key string) (*CachedToken, error) {
+	data, err := ioutil.ReadFile(c.path(key))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	token := &CachedToken{}
+	if err := json.Unmarshal(data, token); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// Store writes the token for key readable only by the current user
+func (c *FileTokenCache) Store(key string, token *CachedToken) error {
+	if err := os.MkdirAll(c.Dir, 0700); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+
+	// write to a temporary file and rename so concurrent invocations never read a partial token
+	tmp, err := ioutil.TempFile(c.Dir, ".token-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), c.path(key))
+}
+
+// Delete removes the token for key, a missing file is not an error
+func (c *FileTokenCache) Delete(key string) error {
+	err := os.Remove(c.path(key))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
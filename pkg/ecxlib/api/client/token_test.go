@@ -0,0 +1,165 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// newTokenTestServer mocks ECX oauth2 endpoints issuing tokens t1, t2... and a metros endpoint that only accepts validToken
+func newTokenTestServer(tokenTimeout string, validToken string, tokens *int32, refreshes *int32) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/oauth2/v1/token", func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(tokens, 1)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token":"t%d","refresh_token":"r%d","token_timeout":"%s","refresh_token_timeout":"3600"}`, n, n, tokenTimeout)
+	})
+	mux.HandleFunc("/oauth2/v1/refreshaccesstoken", func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(refreshes, 1)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token":"refreshed%d","token_timeout":"3600"}`, n)
+	})
+	mux.HandleFunc("/ecx/v3/l2/common/metros", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Header.Get("Authorization") != "Bearer "+validToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"errorCode":"IC-LAYER2-4021","errorMessage":"Unauthorized"}`)
+			return
+		}
+		fmt.Fprint(w, `[]`)
+	})
+	return httptest.NewTLSServer(mux)
+}
+
+func newTokenTestClient(server *httptest.Server) *EquinixAPIClient {
+	endpoint := strings.TrimPrefix(server.URL, "https://")
+	params := &EquinixAPIParams{
+		AppID:     "appid",
+		AppSecret: "secret",
+		GrantType: defaultGrantType,
+		Endpoint:  endpoint,
+	}
+	return NewEcxAPIClient(params, endpoint, true)
+}
+
+func TestReauthenticateOnUnauthorized(t *testing.T) {
+	var tokens, refreshes int32
+	server := newTokenTestServer("3600", "t2", &tokens, &refreshes)
+	defer server.Close()
+
+	ec := newTokenTestClient(server)
+	token, err := ec.GetToken()
+	if err != nil {
+		t.Fatalf("Unexpected error obtaining token: %s", err)
+	}
+
+	// t1 is rejected by the server, the transport must authenticate again and retry with t2
+	if _, _, err := ec.Buyer.Metros.GetMetrosUsingGET(nil, token); err != nil {
+		t.Fatalf("Expected request to succeed after re-authentication, received %s", err)
+	}
+	if tokens != 2 {
+		t.Errorf("Expected 2 token requests, received %d", tokens)
+	}
+}
+
+func TestRefreshBeforeExpiry(t *testing.T) {
+	var tokens, refreshes int32
+	// token timeout lower than tokenExpiryMargin, so it's always about to expire
+	server := newTokenTestServer("1", "refreshed1", &tokens, &refreshes)
+	defer server.Close()
+
+	ec := newTokenTestClient(server)
+	if _, err := ec.GetToken(); err != nil {
+		t.Fatalf("Unexpected error obtaining token: %s", err)
+	}
+
+	token, err := ec.GetToken()
+	if err != nil {
+		t.Fatalf("Unexpected error refreshing token: %s", err)
+	}
+	if refreshes != 1 {
+		t.Errorf("Expected 1 refresh request, received %d", refreshes)
+	}
+
+	if _, _, err := ec.Buyer.Metros.GetMetrosUsingGET(nil, token); err != nil {
+		t.Fatalf("Expected request with refreshed token to succeed, received %s", err)
+	}
+	if tokens != 1 {
+		t.Errorf("Expected 1 token request, received %d", tokens)
+	}
+	if ec.refreshToken != "r1" {
+		t.Errorf("Expected refresh token r1 to be kept, received %s", ec.refreshToken)
+	}
+}
+
+func TestTokenCacheSharedBetweenClients(t *testing.T) {
+	var tokens, refreshes int32
+	server := newTokenTestServer("3600", "t1", &tokens, &refreshes)
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "ecxtokens")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cache, _ := NewFileTokenCache(dir)
+
+	first := newTokenTestClient(server)
+	first.Params.TokenCache = cache
+	if _, err := first.GetToken(); err != nil {
+		t.Fatalf("Unexpected error obtaining token: %s", err)
+	}
+
+	info, err := os.Stat(cache.path(first.tokenCacheKey()))
+	if err != nil {
+		t.Fatalf("Expected cached token file: %s", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected cached token file mode 0600, received %o", info.Mode().Perm())
+	}
+
+	second := newTokenTestClient(server)
+	second.Params.TokenCache = cache
+	token, err := second.GetToken()
+	if err != nil {
+		t.Fatalf("Unexpected error obtaining token: %s", err)
+	}
+	if _, _, err := second.Buyer.Metros.GetMetrosUsingGET(nil, token); err != nil {
+		t.Fatalf("Expected request with cached token to succeed, received %s", err)
+	}
+	if tokens != 1 {
+		t.Errorf("Expected 1 token request, received %d", tokens)
+	}
+
+	second.InvalidateToken()
+	if cached, _ := cache.Load(second.tokenCacheKey()); cached != nil {
+		t.Errorf("Expected cached token to be removed after invalidation")
+	}
+}
+
+func TestGetTokenWithContextCancelled(t *testing.T) {
+	var tokens, refreshes int32
+	server := newTokenTestServer("3600", "t1", &tokens, &refreshes)
+	defer server.Close()
+
+	ec := newTokenTestClient(server)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := ec.GetTokenWithContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, received %v", err)
+	}
+	if n := atomic.LoadInt32(&tokens); n != 0 {
+		t.Errorf("Expected no token request with a cancelled context, received %d", n)
+	}
+	if _, err := ec.GetTokenWithContext(context.Background()); err != nil {
+		t.Errorf("Unexpected error obtaining token: %s", err)
+	}
+}
@@ -0,0 +1,93 @@
+package client
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-openapi/runtime"
+	httptransport "github.com/go-openapi/runtime/client"
+)
+
+// apiTransport wraps the swagger transport retrying transient failures and re-authenticating once when ECX
+// rejects the token, errors returned by ECX are converted to APIError
+type apiTransport struct {
+	transport runtime.ClientTransport
+	client    *EquinixAPIClient
+}
+
+// Submit sends the operation applying the client RetryPolicy
+func (t *apiTransport) Submit(op *runtime.ClientOperation) (interface{}, error) {
+	ctx := op.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	reader := op.Reader
+
+	for attempt := 1; ; attempt++ {
+		result, statusCode, retryAfter, err := t.submit(ctx, op, reader)
+		if err == nil {
+			return result, nil
+		}
+
+		if !t.client.RetryPolicy.shouldRetry(ctx, op, attempt, statusCode, err) {
+			return result, wrapError(err, op, statusCode)
+		}
+
+		wait := t.client.RetryPolicy.backoff(attempt, retryAfter)
+		if t.client.Debug {
+			log.Printf("Attempt %d of %s failed (status %d), retrying in %s\n", attempt, op.ID, statusCode, wait)
+		}
+		if sleep(ctx, wait) != nil {
+			return result, wrapError(err, op, statusCode)
+		}
+	}
+}
+
+// wrapError converts errors with an ECX response to APIError
+func wrapError(err error, op *runtime.ClientOperation, statusCode int) error {
+	if statusCode == 0 {
+		return err
+	}
+	return NewAPIError(err, op.ID, statusCode)
+}
+
+// submit sends the operation once, on a 401 response renews the token and submits it again
+func (t *apiTransport) submit(ctx context.Context, op *runtime.ClientOperation, reader runtime.ClientResponseReader) (interface{}, int, time.Duration, error) {
+	// swagger drops its default timeout for operations with a context, keep it per attempt unless the caller set a deadline
+	op.Context = ctx
+	if _, ok := ctx.Deadline(); !ok {
+		attemptCtx, cancel := context.WithTimeout(ctx, httptransport.DefaultTimeout)
+		defer cancel()
+		op.Context = attemptCtx
+	}
+
+	var statusCode int
+	var retryAfter time.Duration
+	op.Reader = runtime.ClientResponseReaderFunc(func(response runtime.ClientResponse, consumer runtime.Consumer) (interface{}, error) {
+		statusCode = response.Code()
+		retryAfter = parseRetryAfter(response.GetHeader("Retry-After"))
+		return reader.ReadResponse(response, consumer)
+	})
+
+	result, err := t.transport.Submit(op)
+
+	// token operations never carry a bearer token, nothing to renew
+	renewable := op.ID != accessTokenOperationID && op.ID != refreshAccessTokenOperationID && op.AuthInfo != nil
+	if err != nil && statusCode == http.StatusUnauthorized && renewable && t.client.canReauthenticate() {
+		if t.client.Debug {
+			log.Printf("Token rejected on %s, authenticating again\n", op.ID)
+		}
+
+		t.client.InvalidateToken()
+		// keep the original unauthorized error if we can't authenticate, it's more meaningful to the caller
+		if token, tokenErr := t.client.GetTokenWithContext(ctx); tokenErr == nil {
+			op.AuthInfo = token
+			statusCode, retryAfter = 0, 0
+			result, err = t.transport.Submit(op)
+		}
+	}
+
+	return result, statusCode, retryAfter, err
+}